@@ -102,6 +102,38 @@ func BenchmarkNetHTTP(b *testing.B) {
 	})
 }
 
+func benchmarkMiddleware(t int, b *testing.B) {
+	var path string
+	part := "/x"
+	noop := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	s := New(noop)
+	for i := 0; i < t; i++ {
+		path += part
+		s.USE(http.MethodGet, path, noop)
+	}
+	s.GET(path, http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {}))
+	s.Compile()
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, path, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			s.ServeHTTP(w, req)
+		}
+	})
+}
+
 func BenchmarkStatic1(b *testing.B)  { benchmarkStatic(1, b) }
 func BenchmarkStatic2(b *testing.B)  { benchmarkStatic(2, b) }
 func BenchmarkStatic3(b *testing.B)  { benchmarkStatic(3, b) }
@@ -123,6 +155,10 @@ func BenchmarkRegexp5(b *testing.B)  { benchmarkRegexp(5, b) }
 func BenchmarkRegexp10(b *testing.B) { benchmarkRegexp(10, b) }
 func BenchmarkRegexp20(b *testing.B) { benchmarkRegexp(20, b) }
 
+func BenchmarkMiddleware1(b *testing.B)  { benchmarkMiddleware(1, b) }
+func BenchmarkMiddleware5(b *testing.B)  { benchmarkMiddleware(5, b) }
+func BenchmarkMiddleware10(b *testing.B) { benchmarkMiddleware(10, b) }
+
 func benchmarkFastHTTPStatic(t int, b *testing.B) {
 	var path string
 	part := "/x"
@@ -187,6 +223,27 @@ func benchmarkFastHTTPRegexp(t int, b *testing.B) {
 	})
 }
 
+// TestFastHTTPWildcardAllocs checks params do not allocate per value,
+// request path is copied once so params can be kept after the request is served
+func TestFastHTTPWildcardAllocs(t *testing.T) {
+	var path, rpath string
+	part := "/{x}"
+	rpart := "/x"
+	for i := 0; i < 20; i++ {
+		path += part
+		rpath += rpart
+	}
+
+	s := NewFastHTTPRouter()
+	s.GET(path, func(_ *fasthttp.RequestCtx) {})
+
+	ctx := buildFastHTTPRequestContext(http.MethodGet, rpath)
+
+	if allocs := testing.AllocsPerRun(100, func() { s.HandleFastHTTP(ctx) }); allocs > 3 {
+		t.Errorf("Wildcard routing should not allocate per param, got %v allocs", allocs)
+	}
+}
+
 func BenchmarkFastHTTP(b *testing.B) {
 	s := NewFastHTTPRouter()
 	s.GET("/", func(_ *fasthttp.RequestCtx) {})
@@ -201,6 +258,34 @@ func BenchmarkFastHTTP(b *testing.B) {
 	})
 }
 
+func benchmarkFastHTTPMiddleware(t int, b *testing.B) {
+	var path string
+	part := "/x"
+	noop := func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			next(ctx)
+		}
+	}
+
+	s := NewFastHTTPRouter(noop)
+	for i := 0; i < t; i++ {
+		path += part
+		s.USE(http.MethodGet, path, noop)
+	}
+	s.GET(path, func(_ *fasthttp.RequestCtx) {})
+	s.Compile()
+
+	ctx := buildFastHTTPRequestContext(http.MethodGet, path)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			s.HandleFastHTTP(ctx)
+		}
+	})
+}
+
 func BenchmarkFastHTTPStatic1(b *testing.B)  { benchmarkFastHTTPStatic(1, b) }
 func BenchmarkFastHTTPStatic2(b *testing.B)  { benchmarkFastHTTPStatic(2, b) }
 func BenchmarkFastHTTPStatic3(b *testing.B)  { benchmarkFastHTTPStatic(3, b) }
@@ -221,3 +306,7 @@ func BenchmarkFastHTTPRegexp3(b *testing.B)  { benchmarkFastHTTPRegexp(3, b) }
 func BenchmarkFastHTTPRegexp5(b *testing.B)  { benchmarkFastHTTPRegexp(5, b) }
 func BenchmarkFastHTTPRegexp10(b *testing.B) { benchmarkFastHTTPRegexp(10, b) }
 func BenchmarkFastHTTPRegexp20(b *testing.B) { benchmarkFastHTTPRegexp(20, b) }
+
+func BenchmarkFastHTTPMiddleware1(b *testing.B)  { benchmarkFastHTTPMiddleware(1, b) }
+func BenchmarkFastHTTPMiddleware5(b *testing.B)  { benchmarkFastHTTPMiddleware(5, b) }
+func BenchmarkFastHTTPMiddleware10(b *testing.B) { benchmarkFastHTTPMiddleware(10, b) }
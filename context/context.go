@@ -6,13 +6,44 @@ import (
 
 type key struct{}
 
+// Route describes matched route
+type Route struct {
+	Method  string
+	Pattern string
+}
+
+type routeParams struct {
+	params Params
+	route  *Route
+}
+
 // WithParams stores params in context
 func WithParams(ctx context.Context, params Params) context.Context {
 	return context.WithValue(ctx, key{}, params)
 }
 
+// WithRoute stores params along with matched route in context
+func WithRoute(ctx context.Context, params Params, route *Route) context.Context {
+	return context.WithValue(ctx, key{}, routeParams{params: params, route: route})
+}
+
 // Parameters extracts the request Params ctx, if present.
 func Parameters(ctx context.Context) (Params, bool) {
-	params, ok := ctx.Value(key{}).(Params)
-	return params, ok
+	switch v := ctx.Value(key{}).(type) {
+	case Params:
+		return v, true
+	case routeParams:
+		return v.params, v.params != nil
+	default:
+		return nil, false
+	}
+}
+
+// RoutePattern extracts the request matched Route from ctx, if present.
+func RoutePattern(ctx context.Context) (Route, bool) {
+	if v, ok := ctx.Value(key{}).(routeParams); ok && v.route != nil {
+		return *v.route, true
+	}
+
+	return Route{}, false
 }
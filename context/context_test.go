@@ -24,3 +24,40 @@ func TestContext(t *testing.T) {
 		t.Error("Request returned invalid context")
 	}
 }
+
+func TestRoutePattern(t *testing.T) {
+	req, err := http.NewRequest("GET", "/x", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := RoutePattern(req.Context()); ok {
+		t.Fatal("Route pattern should not be present")
+	}
+
+	route := &Route{Method: "GET", Pattern: "/{name}"}
+	params := Params{{"name", "x"}}
+
+	req = req.WithContext(WithRoute(req.Context(), params, route))
+
+	cRoute, ok := RoutePattern(req.Context())
+	if !ok {
+		t.Fatal("Error while getting route pattern")
+	}
+	if cRoute != *route {
+		t.Errorf("Request returned invalid route pattern %v", cRoute)
+	}
+
+	cParams, ok := Parameters(req.Context())
+	if !ok {
+		t.Fatal("Error while getting context")
+	}
+	if cParams.Value("name") != "x" {
+		t.Error("Request returned invalid context")
+	}
+
+	req = req.WithContext(WithRoute(req.Context(), nil, route))
+	if _, ok := Parameters(req.Context()); ok {
+		t.Error("Params should not be present")
+	}
+}
@@ -1,5 +1,14 @@
 package context
 
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/vardius/gorouter/v4/internal/format"
+)
+
 type (
 	// Param object to hold request parameter
 	Param struct {
@@ -10,6 +19,30 @@ type (
 	Params []Param
 )
 
+// ErrParamNotFound is returned by typed accessors when param is absent
+var ErrParamNotFound = errors.New("param not found")
+
+// ParamError describes param which value could not be converted to requested type
+type ParamError struct {
+	Key   string
+	Value string
+	Type  string
+	Err   error
+}
+
+func (e *ParamError) Error() string {
+	if errors.Is(e.Err, ErrParamNotFound) {
+		return fmt.Sprintf("param %q: %s", e.Key, e.Err)
+	}
+
+	return fmt.Sprintf("param %q: invalid %s value %q: %s", e.Key, e.Type, e.Value, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *ParamError) Unwrap() error {
+	return e.Err
+}
+
 // Value of the request parameter by name
 func (p Params) Value(key string) string {
 	for i := range p {
@@ -20,8 +53,143 @@ func (p Params) Value(key string) string {
 	return ""
 }
 
+// Has checks if the request parameter is present, even if its value is empty
+func (p Params) Has(key string) bool {
+	for i := range p {
+		if p[i].Key == key {
+			return true
+		}
+	}
+	return false
+}
+
 // Set key value pair at index
 func (p Params) Set(index uint8, key string, value string) {
 	p[index].Value = value
 	p[index].Key = key
 }
+
+// Int returns the request parameter value converted to int
+func (p Params) Int(key string) (int, error) {
+	v, err := p.lookup(key, "int")
+	if err != nil {
+		return 0, err
+	}
+
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, newParamError(key, v, "int", err)
+	}
+
+	return i, nil
+}
+
+// Int64 returns the request parameter value converted to int64
+func (p Params) Int64(key string) (int64, error) {
+	v, err := p.lookup(key, "int64")
+	if err != nil {
+		return 0, err
+	}
+
+	i, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, newParamError(key, v, "int64", err)
+	}
+
+	return i, nil
+}
+
+// Uint returns the request parameter value converted to uint
+func (p Params) Uint(key string) (uint, error) {
+	v, err := p.lookup(key, "uint")
+	if err != nil {
+		return 0, err
+	}
+
+	i, err := strconv.ParseUint(v, 10, 0)
+	if err != nil {
+		return 0, newParamError(key, v, "uint", err)
+	}
+
+	return uint(i), nil
+}
+
+// Float64 returns the request parameter value converted to float64
+func (p Params) Float64(key string) (float64, error) {
+	v, err := p.lookup(key, "float64")
+	if err != nil {
+		return 0, err
+	}
+
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, newParamError(key, v, "float64", err)
+	}
+
+	return f, nil
+}
+
+// Bool returns the request parameter value converted to bool,
+// accepts values supported by strconv.ParseBool
+func (p Params) Bool(key string) (bool, error) {
+	v, err := p.lookup(key, "bool")
+	if err != nil {
+		return false, err
+	}
+
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, newParamError(key, v, "bool", err)
+	}
+
+	return b, nil
+}
+
+// UUID returns the request parameter value validated
+// to be UUID in its canonical textual representation
+func (p Params) UUID(key string) (string, error) {
+	v, err := p.lookup(key, "uuid")
+	if err != nil {
+		return "", err
+	}
+
+	if !format.IsUUID(v) {
+		return "", newParamError(key, v, "uuid", errors.New("invalid format"))
+	}
+
+	return v, nil
+}
+
+// Time returns the request parameter value parsed with given layout
+func (p Params) Time(key, layout string) (time.Time, error) {
+	v, err := p.lookup(key, "time")
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	t, err := time.Parse(layout, v)
+	if err != nil {
+		return time.Time{}, newParamError(key, v, "time", err)
+	}
+
+	return t, nil
+}
+
+func (p Params) lookup(key, typ string) (string, error) {
+	for i := range p {
+		if p[i].Key == key {
+			return p[i].Value, nil
+		}
+	}
+
+	return "", newParamError(key, "", typ, ErrParamNotFound)
+}
+
+func newParamError(key, value, typ string, err error) error {
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		err = numErr.Err
+	}
+
+	return &ParamError{Key: key, Value: value, Type: typ, Err: err}
+}
@@ -1,7 +1,10 @@
 package context
 
 import (
+	"errors"
+	"strconv"
 	"testing"
+	"time"
 )
 
 func TestParamValue(t *testing.T) {
@@ -44,3 +47,82 @@ func TestParamsSet(t *testing.T) {
 		})
 	}
 }
+
+func TestParamsHas(t *testing.T) {
+	params := Params{{"empty", ""}, {"key", "value"}}
+
+	if !params.Has("empty") {
+		t.Error("Empty parameter should be present")
+	}
+	if !params.Has("key") {
+		t.Error("Parameter should be present")
+	}
+	if params.Has("missing") {
+		t.Error("Missing parameter should not be present")
+	}
+}
+
+func TestParamsTyped(t *testing.T) {
+	params := Params{
+		{"int", "-42"},
+		{"uint", "42"},
+		{"float", "4.2"},
+		{"bool", "true"},
+		{"uuid", "123e4567-e89b-12d3-a456-426614174000"},
+		{"date", "2020-01-02"},
+		{"text", "abc"},
+		{"empty", ""},
+	}
+
+	tests := []struct {
+		name    string
+		fn      func() (interface{}, error)
+		want    interface{}
+		wantErr bool
+		errIs   error
+	}{
+		{"int", func() (interface{}, error) { return params.Int("int") }, -42, false, nil},
+		{"int invalid", func() (interface{}, error) { return params.Int("text") }, 0, true, strconv.ErrSyntax},
+		{"int empty", func() (interface{}, error) { return params.Int("empty") }, 0, true, strconv.ErrSyntax},
+		{"int missing", func() (interface{}, error) { return params.Int("missing") }, 0, true, ErrParamNotFound},
+		{"int64", func() (interface{}, error) { return params.Int64("int") }, int64(-42), false, nil},
+		{"int64 invalid", func() (interface{}, error) { return params.Int64("float") }, int64(0), true, strconv.ErrSyntax},
+		{"uint", func() (interface{}, error) { return params.Uint("uint") }, uint(42), false, nil},
+		{"uint negative", func() (interface{}, error) { return params.Uint("int") }, uint(0), true, strconv.ErrSyntax},
+		{"float64", func() (interface{}, error) { return params.Float64("float") }, 4.2, false, nil},
+		{"float64 invalid", func() (interface{}, error) { return params.Float64("text") }, float64(0), true, strconv.ErrSyntax},
+		{"bool", func() (interface{}, error) { return params.Bool("bool") }, true, false, nil},
+		{"bool invalid", func() (interface{}, error) { return params.Bool("text") }, false, true, strconv.ErrSyntax},
+		{"uuid", func() (interface{}, error) { return params.UUID("uuid") }, "123e4567-e89b-12d3-a456-426614174000", false, nil},
+		{"uuid invalid", func() (interface{}, error) { return params.UUID("text") }, "", true, nil},
+		{"uuid missing", func() (interface{}, error) { return params.UUID("missing") }, "", true, ErrParamNotFound},
+		{"time", func() (interface{}, error) { return params.Time("date", "2006-01-02") }, time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), false, nil},
+		{"time invalid", func() (interface{}, error) { return params.Time("text", "2006-01-02") }, time.Time{}, true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.fn()
+			if got != tt.want {
+				t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+			}
+
+			if !tt.wantErr {
+				if err != nil {
+					t.Errorf("%s: unexpected error %v", tt.name, err)
+				}
+				return
+			}
+
+			var paramErr *ParamError
+			if !errors.As(err, &paramErr) {
+				t.Fatalf("%s: expected ParamError, got %v", tt.name, err)
+			}
+			if paramErr.Key == "" || paramErr.Type == "" {
+				t.Errorf("%s: ParamError should name param and type, got %+v", tt.name, paramErr)
+			}
+			if tt.errIs != nil && !errors.Is(err, tt.errIs) {
+				t.Errorf("%s: expected error %v, got %v", tt.name, tt.errIs, err)
+			}
+		})
+	}
+}
@@ -13,7 +13,13 @@ not any routes after that branch. When instantiating router, the root node of tr
 
 - Named `/{name}` (will match requests matching given route scheme)
 
-- Regexp `/{name:[a-z]+}` (will match requests matching given route scheme and its regexp)
+- Regexp `/{name:[a-z]+}` (will match requests matching given route scheme and its regexp,
+regexp has to match the whole path part, prefix it with `~` to allow partial match `/{name:~[a-z]+}`)
+
+- Constraint `/{name:int}` (will match requests matching given route scheme and constraint:
+int, uint, uuid, alpha, alnum, hex, date or one registered with mux.RegisterConstraint)
+
+- Catch-all `/{name*}` (will match the rest of the request path, including slashes)
 
 # Wildcards
 
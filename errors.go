@@ -0,0 +1,56 @@
+package gorouter
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/vardius/gorouter/v4/context"
+)
+
+// HTTPError is an error carrying the status code used to reply to the request
+// by the default error handlers, its message is sent as response body
+type HTTPError interface {
+	error
+	StatusCode() int
+}
+
+// PanicError wraps value recovered from error returning handler panic
+type PanicError struct {
+	Value interface{}
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// DefaultErrorHandler replies to the request with HTTPError status code and message,
+// context.ParamError is replied with the 400 Error code, other errors with the 500 Error code
+// without exposing their message
+func DefaultErrorHandler(w http.ResponseWriter, _ *http.Request, err error) {
+	code, msg := errorResponse(err)
+	http.Error(w, msg, code)
+}
+
+// DefaultFastHTTPErrorHandler replies to the request the same way DefaultErrorHandler does
+func DefaultFastHTTPErrorHandler(ctx *fasthttp.RequestCtx, err error) {
+	code, msg := errorResponse(err)
+	ctx.Error(msg, code)
+}
+
+// errorResponse returns status code and message used to reply to the request with error
+func errorResponse(err error) (int, string) {
+	var httpErr HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode(), httpErr.Error()
+	}
+
+	var paramErr *context.ParamError
+	if errors.As(err, &paramErr) && !errors.Is(paramErr, context.ErrParamNotFound) {
+		return http.StatusBadRequest, paramErr.Error()
+	}
+
+	return http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError)
+}
@@ -1,12 +1,15 @@
 package gorouter
 
 import (
+	"errors"
+	"sort"
 	"strings"
 
 	pathutils "github.com/vardius/gorouter/v4/path"
 
 	"github.com/valyala/fasthttp"
 
+	"github.com/vardius/gorouter/v4/context"
 	"github.com/vardius/gorouter/v4/middleware"
 	"github.com/vardius/gorouter/v4/mux"
 )
@@ -25,123 +28,491 @@ var allFasthttpMethods = []string{
 
 // NewFastHTTPRouter creates new Router instance, returns pointer
 func NewFastHTTPRouter(fs ...FastHTTPMiddlewareFunc) FastHTTPRouter {
-	globalMiddleware := transformFastHTTPMiddlewareFunc(fs...)
+	return NewFastHTTPRouterWithOptions(WithFastHTTPMiddleware(fs...))
+}
+
+// NewFastHTTPRouterWithOptions creates new Router instance configured with given options, returns pointer
+func NewFastHTTPRouterWithOptions(opts ...FastHTTPOption) FastHTTPRouter {
+	c := newFastHTTPConfig(opts...)
+	globalMiddleware := transformFastHTTPMiddlewareFunc(c.fastHTTPMiddleware...)
 
 	r := &fastHTTPRouter{
 		tree:              mux.NewTree(),
+		routes:            make(namedRoutes),
 		globalMiddleware:  globalMiddleware,
 		middlewareCounter: uint(len(globalMiddleware)),
+		headFallback:      c.headFallback,
+		autoOptions:       c.autoOptions,
+		routePattern:      c.routePattern,
+		rawPath:           c.rawPath,
+	}
+	r.live.concurrent = c.concurrent
+	r.live.settings = &fastHTTPSettings{
+		notFound:          c.fastHTTPNotFound,
+		notAllowed:        c.fastHTTPNotAllowed,
+		errorHandler:      c.fastHTTPErrorHandler,
+		optionsHandler:    c.fastHTTPOptionsHandler,
+		trailingSlash:     c.trailingSlash,
+		redirectCleanPath: c.redirectCleanPath,
 	}
 
 	r.handler = globalMiddleware.Compose(fasthttp.RequestHandler(r.serveHTTP)).(fasthttp.RequestHandler)
 
+	if c.fastHTTPPanicHandler != nil {
+		r.handler = newFastHTTPPanicRecoverer(r.handler, c.fastHTTPPanicHandler)
+	}
+
 	return r
 }
 
+// FastHTTPRoutePattern extracts the request matched route from ctx, if present,
+// router has to be created with WithRoutePattern option
+func FastHTTPRoutePattern(ctx *fasthttp.RequestCtx) (context.Route, bool) {
+	if route, ok := ctx.UserValue("route").(*context.Route); ok {
+		return *route, true
+	}
+
+	return context.Route{}, false
+}
+
 type fastHTTPRouter struct {
 	tree              mux.Tree
+	routes            namedRoutes
 	globalMiddleware  middleware.Collection
+	handler           fasthttp.RequestHandler
+	middlewareCounter uint
+	compiled          bool
+	headFallback      bool
+	autoOptions       bool
+	ignoreCase        bool
+	routePattern      bool
+	rawPath           bool
+	hosts             mux.Tree
+	hostRouters       map[string]*fastHTTPRouter
+	hostScoped        bool
+	handlers          composedHandlers
+	live              liveRoutes
+}
+
+// fastHTTPSettings are router settings which can be changed while serving requests,
+// they are never changed, setters replace them with changed copy
+type fastHTTPSettings struct {
 	fileServer        fasthttp.RequestHandler
 	notFound          fasthttp.RequestHandler
 	notAllowed        fasthttp.RequestHandler
-	handler           fasthttp.RequestHandler
-	middlewareCounter uint
+	errorHandler      func(*fasthttp.RequestCtx, error)
+	optionsHandler    fasthttp.RequestHandler
+	trailingSlash     TrailingSlashPolicy
+	redirectCleanPath bool
+	redirectCase      bool
 }
 
-func (r *fastHTTPRouter) PrettyPrint() string {
-	return r.tree.PrettyPrint()
+// configure changes copy of router settings
+func (r *fastHTTPRouter) configure(fn func(s *fastHTTPSettings)) {
+	r.live.configure(&r.tree, &r.hosts, func() interface{} {
+		s := *r.live.settings.(*fastHTTPSettings)
+		fn(&s)
+
+		return &s
+	})
 }
 
-func (r *fastHTTPRouter) POST(p string, f fasthttp.RequestHandler) {
-	r.Handle(fasthttp.MethodPost, p, f)
+func (r *fastHTTPRouter) PrettyPrint() (tree string) {
+	r.live.read(func() {
+		tree = r.tree.PrettyPrint()
+	})
+
+	return tree
 }
 
-func (r *fastHTTPRouter) GET(p string, f fasthttp.RequestHandler) {
-	r.Handle(fasthttp.MethodGet, p, f)
+func (r *fastHTTPRouter) POST(p string, f fasthttp.RequestHandler, name ...string) {
+	r.Handle(fasthttp.MethodPost, p, f, name...)
 }
 
-func (r *fastHTTPRouter) PUT(p string, f fasthttp.RequestHandler) {
-	r.Handle(fasthttp.MethodPut, p, f)
+func (r *fastHTTPRouter) GET(p string, f fasthttp.RequestHandler, name ...string) {
+	r.Handle(fasthttp.MethodGet, p, f, name...)
 }
 
-func (r *fastHTTPRouter) DELETE(p string, f fasthttp.RequestHandler) {
-	r.Handle(fasthttp.MethodDelete, p, f)
+func (r *fastHTTPRouter) PUT(p string, f fasthttp.RequestHandler, name ...string) {
+	r.Handle(fasthttp.MethodPut, p, f, name...)
 }
 
-func (r *fastHTTPRouter) PATCH(p string, f fasthttp.RequestHandler) {
-	r.Handle(fasthttp.MethodPatch, p, f)
+func (r *fastHTTPRouter) DELETE(p string, f fasthttp.RequestHandler, name ...string) {
+	r.Handle(fasthttp.MethodDelete, p, f, name...)
 }
 
-func (r *fastHTTPRouter) OPTIONS(p string, f fasthttp.RequestHandler) {
-	r.Handle(fasthttp.MethodOptions, p, f)
+func (r *fastHTTPRouter) PATCH(p string, f fasthttp.RequestHandler, name ...string) {
+	r.Handle(fasthttp.MethodPatch, p, f, name...)
 }
 
-func (r *fastHTTPRouter) HEAD(p string, f fasthttp.RequestHandler) {
-	r.Handle(fasthttp.MethodHead, p, f)
+func (r *fastHTTPRouter) OPTIONS(p string, f fasthttp.RequestHandler, name ...string) {
+	r.Handle(fasthttp.MethodOptions, p, f, name...)
 }
 
-func (r *fastHTTPRouter) CONNECT(p string, f fasthttp.RequestHandler) {
-	r.Handle(fasthttp.MethodConnect, p, f)
+func (r *fastHTTPRouter) HEAD(p string, f fasthttp.RequestHandler, name ...string) {
+	r.Handle(fasthttp.MethodHead, p, f, name...)
 }
 
-func (r *fastHTTPRouter) TRACE(p string, f fasthttp.RequestHandler) {
-	r.Handle(fasthttp.MethodTrace, p, f)
+func (r *fastHTTPRouter) CONNECT(p string, f fasthttp.RequestHandler, name ...string) {
+	r.Handle(fasthttp.MethodConnect, p, f, name...)
 }
 
-func (r *fastHTTPRouter) USE(method, path string, fs ...FastHTTPMiddlewareFunc) {
-	m := transformFastHTTPMiddlewareFunc(fs...)
-	for i, mf := range m {
-		m[i] = middleware.WithPriority(mf, r.middlewareCounter)
-	}
+func (r *fastHTTPRouter) TRACE(p string, f fasthttp.RequestHandler, name ...string) {
+	r.Handle(fasthttp.MethodTrace, p, f, name...)
+}
 
-	r.tree = r.tree.WithMiddleware(method+path, m, 0)
-	r.middlewareCounter += uint(len(m))
+func (r *fastHTTPRouter) USE(method, path string, fs ...FastHTTPMiddlewareFunc) {
+	r.useMiddleware([]string{method}, path, fs...)
 }
 
 func (r *fastHTTPRouter) USEANY(path string, fs ...FastHTTPMiddlewareFunc) {
+	r.useMiddleware(allFasthttpMethods, path, fs...)
+}
+
+func (r *fastHTTPRouter) useMiddleware(methods []string, path string, fs ...FastHTTPMiddlewareFunc) {
+	path = cleanPattern(path)
+
 	m := transformFastHTTPMiddlewareFunc(fs...)
-	for i, mf := range m {
-		m[i] = middleware.WithPriority(mf, r.middlewareCounter)
+
+	r.live.update(&r.tree, &r.hosts, func() {
+		for i, mf := range m {
+			m[i] = middleware.WithPriority(mf, r.middlewareCounter)
+		}
+
+		for _, method := range methods {
+			r.tree = r.tree.WithMiddleware(method+path, m, 0)
+
+			if r.ignoreCase {
+				r.tree.IgnorePathCase(method + path)
+			}
+		}
+
+		r.middlewareCounter += uint(len(m))
+
+		if r.compiled {
+			r.live.compose(r.tree, &r.handlers)
+		}
+	})
+}
+
+func (r *fastHTTPRouter) Handle(method, path string, h fasthttp.RequestHandler, name ...string) {
+	if err := r.TryHandle(method, path, h, name...); err != nil {
+		panic(err)
 	}
+}
 
-	for _, method := range allFasthttpMethods {
-		r.tree = r.tree.WithMiddleware(method+path, m, 0)
+func (r *fastHTTPRouter) TryHandle(method, path string, h fasthttp.RequestHandler, name ...string) (err error) {
+	if h == nil {
+		return errors.New("handler can not be nil")
 	}
 
-	r.middlewareCounter += uint(len(m))
+	r.live.update(&r.tree, &r.hosts, func() {
+		err = r.handle(method, path, h, name...)
+	})
+
+	return err
 }
 
-func (r *fastHTTPRouter) Handle(method, path string, h fasthttp.RequestHandler) {
+// handle registers route, router trees have to be updated exclusively
+func (r *fastHTTPRouter) handle(method, path string, h fasthttp.RequestHandler, name ...string) error {
+	path = cleanPattern(path)
+
+	if err := r.routes.check(name); err != nil {
+		return err
+	}
+	if err := checkRoute(r.tree, method, path, false); err != nil {
+		return err
+	}
+
 	route := newRoute(h)
+	route.trailingSlash = hasTrailingSlash(path)
+	route.pattern = context.Route{Method: method, Pattern: path}
 
 	r.tree = r.tree.WithRoute(method+path, route, 0)
+	r.routes.add(method, route, name)
+
+	if r.ignoreCase {
+		r.tree.IgnorePathCase(method + path)
+	}
+
+	return nil
+}
+
+func (r *fastHTTPRouter) Replace(method, path string, h fasthttp.RequestHandler) {
+	path = cleanPattern(path)
+
+	if h == nil {
+		panic("Handler can not be nil.")
+	}
+
+	var err error
+	r.live.update(&r.tree, &r.hosts, func() {
+		if !replaceRoute(r.tree, r.routes, method, path, h) {
+			err = r.handle(method, path, h)
+			return
+		}
+
+		if r.compiled {
+			r.live.compose(r.tree, &r.handlers)
+		}
+	})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (r *fastHTTPRouter) Remove(method, path string) (removed bool) {
+	path = cleanPattern(path)
+
+	r.live.update(&r.tree, &r.hosts, func() {
+		var rt *route
+		if r.tree, rt = removeRoute(r.tree, method, path); rt == nil {
+			return
+		}
+
+		r.routes.remove(rt)
+		removed = true
+
+		if r.compiled {
+			// removed nodes may leave static nodes which can be compiled together
+			compileTree(r.tree)
+			r.live.compose(r.tree, &r.handlers)
+		}
+	})
+
+	return removed
+}
+
+func (r *fastHTTPRouter) HandleE(method, path string, h func(*fasthttp.RequestCtx) error, name ...string) {
+	if h == nil {
+		panic("Handler can not be nil.")
+	}
+
+	r.Handle(method, path, func(ctx *fasthttp.RequestCtx) {
+		defer func() {
+			if rcv := recover(); rcv != nil {
+				r.serveError(ctx, &PanicError{Value: rcv})
+			}
+		}()
+
+		if err := h(ctx); err != nil {
+			r.serveError(ctx, err)
+		}
+	}, name...)
+}
+
+func (r *fastHTTPRouter) URL(name string, params ...string) (url string, err error) {
+	r.live.read(func() {
+		url, err = buildURL(r.tree, r.routes, name, params...)
+	})
+
+	return url, err
 }
 
 func (r *fastHTTPRouter) Mount(path string, h fasthttp.RequestHandler) {
-	pathRewrite := fasthttp.NewPathSlashesStripper(strings.Count(path, "/"))
-	route := newRoute(fasthttp.RequestHandler(func(ctx *fasthttp.RequestCtx) {
-		ctx.URI().SetPathBytes(pathRewrite(ctx))
+	path = cleanPattern(path)
+	stripSlashes := strings.Count(path, "/")
+	pathRewrite := fasthttp.NewPathSlashesStripper(stripSlashes)
+	handler := fasthttp.RequestHandler(func(ctx *fasthttp.RequestCtx) {
+		if r.rawPath {
+			// setting decoded path loses encoded slashes, original path is stripped instead
+			ctx.URI().SetPath(pathutils.StripLeadingSlashes(string(ctx.URI().PathOriginal()), stripSlashes))
+		} else {
+			ctx.URI().SetPathBytes(pathRewrite(ctx))
+		}
 
 		h(ctx)
-	}))
+	})
+
+	var err error
+	r.live.update(&r.tree, &r.hosts, func() {
+		for _, method := range allFasthttpMethods {
+			if err = checkRoute(r.tree, method, path, true); err != nil {
+				return
+			}
+		}
+
+		for _, method := range allFasthttpMethods {
+			// route per method, each of them has its own middleware
+			route := newRoute(handler)
+			route.subrouter = true
+			route.pattern = context.Route{Method: method, Pattern: path}
+
+			r.tree = r.tree.WithSubrouter(method+path, route, 0)
+
+			if r.ignoreCase {
+				r.tree.IgnorePathCase(method + path)
+			}
+		}
+	})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (r *fastHTTPRouter) Update(fn func(r FastHTTPRouter)) {
+	r.live.batch(&r.tree, &r.hosts, func() {
+		fn(r)
+	})
+}
+
+func (r *fastHTTPRouter) Group(prefix string, fn func(g FastHTTPRouteGroup)) {
+	fn(&fastHTTPGroup{fastHTTPRouter: r, prefix: prefix})
+}
+
+func (r *fastHTTPRouter) Host(pattern string) FastHTTPRouter {
+	var (
+		h   *fastHTTPRouter
+		err error
+	)
+
+	r.live.update(&r.tree, &r.hosts, func() {
+		h, err = r.host(pattern)
+	})
+
+	if err != nil {
+		panic(err)
+	}
+
+	return h
+}
+
+// host returns router scoped to the host pattern, creating it if needed
+func (r *fastHTTPRouter) host(pattern string) (*fastHTTPRouter, error) {
+	if h, ok := r.hostRouters[pattern]; ok {
+		return h, nil
+	}
+	if err := checkHost(r.hosts, pattern); err != nil {
+		return nil, err
+	}
+
+	settings := *r.live.settings.(*fastHTTPSettings)
+	settings.fileServer = nil
+
+	h := &fastHTTPRouter{
+		tree:         mux.NewTree(),
+		routes:       make(namedRoutes),
+		headFallback: r.headFallback,
+		autoOptions:  r.autoOptions,
+		ignoreCase:   r.ignoreCase,
+		routePattern: r.routePattern,
+		rawPath:      r.rawPath,
+		hostScoped:   true,
+	}
+	h.live.concurrent = r.live.concurrent
+	h.live.settings = &settings
+	h.handler = fasthttp.RequestHandler(h.serveHTTP)
+
+	if r.hostRouters == nil {
+		r.hostRouters = make(map[string]*fastHTTPRouter)
+	}
+
+	r.hostRouters[pattern] = h
+	hostRoute := newRoute(h)
+	hostRoute.pattern = context.Route{Pattern: pattern}
+
+	r.hosts = r.hosts.WithRoute(hostPatternPath(pattern), hostRoute, 0)
+
+	return h, nil
+}
+
+func (r *fastHTTPRouter) Routes() []RouteInfo {
+	var (
+		routes      []RouteInfo
+		hostRouters map[string]*fastHTTPRouter
+	)
+
+	r.live.read(func() {
+		routes = routesInfo(r.tree)
 
-	for _, method := range allFasthttpMethods {
-		r.tree = r.tree.WithSubrouter(method+path, route, 0)
+		hostRouters = make(map[string]*fastHTTPRouter, len(r.hostRouters))
+		for pattern, h := range r.hostRouters {
+			hostRouters[pattern] = h
+		}
+	})
+
+	hosts := make([]string, 0, len(hostRouters))
+	for pattern := range hostRouters {
+		hosts = append(hosts, pattern)
+	}
+	sort.Strings(hosts)
+
+	for _, pattern := range hosts {
+		for _, info := range hostRouters[pattern].Routes() {
+			if info.Host == "" {
+				info.Host = pattern
+			}
+
+			routes = append(routes, info)
+		}
 	}
+
+	return routes
 }
 
 func (r *fastHTTPRouter) Compile() {
-	for i, methodNode := range r.tree {
-		r.tree[i].WithChildren(methodNode.Tree().Compile())
+	var hostRouters []*fastHTTPRouter
+
+	r.live.update(&r.tree, &r.hosts, func() {
+		compileTree(r.tree)
+		r.live.compose(r.tree, &r.handlers)
+		r.compiled = true
+
+		for _, h := range r.hostRouters {
+			hostRouters = append(hostRouters, h)
+		}
+	})
+
+	for _, h := range hostRouters {
+		h.Compile()
 	}
 }
 
 func (r *fastHTTPRouter) NotFound(notFound fasthttp.RequestHandler) {
-	r.notFound = notFound
+	r.configure(func(s *fastHTTPSettings) {
+		s.notFound = notFound
+	})
+}
+
+func (r *fastHTTPRouter) AutoOptions(h fasthttp.RequestHandler) {
+	r.configure(func(s *fastHTTPSettings) {
+		s.optionsHandler = h
+	})
 }
 
 func (r *fastHTTPRouter) NotAllowed(notAllowed fasthttp.RequestHandler) {
-	r.notAllowed = notAllowed
+	r.configure(func(s *fastHTTPSettings) {
+		s.notAllowed = notAllowed
+	})
+}
+
+func (r *fastHTTPRouter) OnError(fn func(ctx *fasthttp.RequestCtx, err error)) {
+	r.configure(func(s *fastHTTPSettings) {
+		s.errorHandler = fn
+	})
+}
+
+func (r *fastHTTPRouter) TrailingSlash(policy TrailingSlashPolicy) {
+	r.configure(func(s *fastHTTPSettings) {
+		s.trailingSlash = policy
+	})
+}
+
+func (r *fastHTTPRouter) RedirectCleanPath(redirect bool) {
+	r.configure(func(s *fastHTTPSettings) {
+		s.redirectCleanPath = redirect
+	})
+}
+
+func (r *fastHTTPRouter) CaseInsensitive(redirect bool) {
+	r.configure(func(s *fastHTTPSettings) {
+		s.redirectCase = redirect
+
+		r.ignoreCase = true
+		r.tree.IgnoreCase()
+	})
 }
 
 func (r *fastHTTPRouter) ServeFiles(root string, stripSlashes int) {
@@ -149,7 +520,10 @@ func (r *fastHTTPRouter) ServeFiles(root string, stripSlashes int) {
 		panic("gorouter.ServeFiles: empty root!")
 	}
 
-	r.fileServer = fasthttp.FSHandler(root, stripSlashes)
+	handler := fasthttp.FSHandler(root, stripSlashes)
+	r.configure(func(s *fastHTTPSettings) {
+		s.fileServer = handler
+	})
 }
 
 func (r *fastHTTPRouter) HandleFastHTTP(ctx *fasthttp.RequestCtx) {
@@ -158,91 +532,219 @@ func (r *fastHTTPRouter) HandleFastHTTP(ctx *fasthttp.RequestCtx) {
 
 func (r *fastHTTPRouter) serveHTTP(ctx *fasthttp.RequestCtx) {
 	method := string(ctx.Method())
+	// path is copied once, param values are its substrings and may be kept after the request is served
 	path := string(ctx.Path())
+	if r.rawPath {
+		path = pathutils.Unescape(string(ctx.URI().PathOriginal()))
+	}
 
-	if root := r.tree.Find(method); root != nil {
-		var h fasthttp.RequestHandler
-
-		if path == "/" {
-			if root.Route() != nil && root.Route().Handler() != nil {
-				if r.middlewareCounter > 0 {
-					computedHandler := root.Middleware().Sort().Compose(root.Route().Handler())
-
-					h = computedHandler.(fasthttp.RequestHandler)
-				} else {
-					h = root.Route().Handler().(fasthttp.RequestHandler)
-				}
+	table := r.live.load(&r.tree, &r.hosts, r.handlers)
+	s := table.settings.(*fastHTTPSettings)
 
-				h(ctx)
-				return
+	// Handle host routers
+	if len(table.hosts) > 0 {
+		if rt, params := table.hosts.MatchRoute(hostPath(string(ctx.Host()))); rt != nil {
+			if len(params) > 0 {
+				ctx.SetUserValue("params", params)
 			}
-		} else {
-			path = pathutils.TrimSlash(path)
-
-			if route, params := root.Tree().MatchRoute(path); route != nil {
-				if r.middlewareCounter > 0 {
-					var allMiddleware middleware.Collection
-					if treeMiddleware := root.Tree().MatchMiddleware(path); len(treeMiddleware) > 0 {
-						allMiddleware = root.Middleware().Merge(treeMiddleware).Sort()
-					} else {
-						allMiddleware = root.Middleware().Sort()
-					}
-
-					computedHandler := allMiddleware.Compose(route.Handler())
 
-					h = computedHandler.(fasthttp.RequestHandler)
-				} else {
-					h = route.Handler().(fasthttp.RequestHandler)
-				}
-
-				if len(params) > 0 {
-					ctx.SetUserValue("params", params)
-				}
+			rt.Handler().(*fastHTTPRouter).HandleFastHTTP(ctx)
+			return
+		}
+	}
 
-				h(ctx)
-				return
-			}
+	// Handle not clean path, fasthttp normalizes request path so original one has to be checked
+	if s.redirectCleanPath {
+		original := string(ctx.URI().PathOriginal())
+		if cleaned := pathutils.Clean(original); cleaned != original {
+			r.redirect(ctx, cleaned)
+			return
 		}
 	}
 
-	path = pathutils.TrimSlash(path)
+	if r.serveRoute(ctx, table, method, path) {
+		return
+	}
+
+	// Handle HEAD with GET route
+	if method == fasthttp.MethodHead && r.headFallback && r.serveRoute(ctx, table, fasthttp.MethodGet, path) {
+		ctx.Response.SkipBody = true
+		return
+	}
 
 	// Handle file serve
-	if method == fasthttp.MethodGet && r.fileServer != nil {
-		r.fileServer(ctx)
+	if method == fasthttp.MethodGet && s.fileServer != nil {
+		s.fileServer(ctx)
 		return
 	}
 
 	// Handle OPTIONS
-	if allow := allowed(r.tree, method, path); len(allow) > 0 {
+	if allow := allowed(table.tree, method, path, r.headFallback, s.trailingSlash != TrailingSlashLenient); len(allow) > 0 {
 		ctx.Response.Header.Set("Allow", allow)
 
-		if method == fasthttp.MethodOptions {
+		if method == fasthttp.MethodOptions && r.autoOptions {
+			if s.optionsHandler != nil {
+				s.optionsHandler(ctx)
+			}
 			return
 		}
 
 		// Handle 405
-		r.serveNotAllowed(ctx)
+		s.serveNotAllowed(ctx)
 		return
 	}
 
 	// Handle 404
-	r.serveNotFound(ctx)
+	s.serveNotFound(ctx)
 }
 
-func (r *fastHTTPRouter) serveNotFound(ctx *fasthttp.RequestCtx) {
-	if r.notFound != nil {
-		r.notFound(ctx)
+// serveRoute dispatches the request to the route registered under given method,
+// reports if route was found
+func (r *fastHTTPRouter) serveRoute(ctx *fasthttp.RequestCtx, table routeTable, method, path string) bool {
+	s := table.settings.(*fastHTTPSettings)
+
+	root := table.tree.Find(method)
+	if root == nil {
+		return false
+	}
+
+	var (
+		rt      mux.Route
+		params  context.Params
+		trimmed string
+	)
+
+	if path == "/" {
+		rt = root.Route()
+	} else {
+		trimmed = pathutils.TrimSlash(path)
+		rt, params = root.Tree().MatchRoute(trimmed)
+	}
+
+	if rt == nil {
+		return false
+	}
+
+	// Handle trailing slash
+	if s.trailingSlash != TrailingSlashLenient && !rt.(*route).matchesSlash(path) {
+		if s.trailingSlash == TrailingSlashStrict {
+			return false
+		}
+
+		r.redirect(ctx, toggleTrailingSlash(path))
+		return true
+	}
+
+	// Handle registered path casing
+	if s.redirectCase && trimmed != "" && !rt.(*route).subrouter {
+		if cased, ok := rt.(*route).casedPath(trimmed, params); ok {
+			if hasTrailingSlash(path) {
+				cased += "/"
+			}
+
+			r.redirect(ctx, "/"+cased)
+			return true
+		}
+	}
+
+	if r.rawPath {
+		unescapeParams(params)
+	}
+
+	h := r.routeHandler(table.handlers, root, rt, trimmed)
+
+	// Handle host params
+	if r.hostScoped {
+		if hostParams, ok := ctx.UserValue("params").(context.Params); ok {
+			params = append(hostParams[:len(hostParams):len(hostParams)], params...)
+		}
+	}
+
+	if len(params) > 0 {
+		ctx.SetUserValue("params", params)
+	}
+
+	if r.routePattern {
+		ctx.SetUserValue("route", &rt.(*route).pattern)
+	}
+
+	h(ctx)
+	return true
+}
+
+// redirect replies to the request with redirect to given path keeping the query string
+func (r *fastHTTPRouter) redirect(ctx *fasthttp.RequestCtx, path string) {
+	if r.rawPath {
+		path = escapePath(path)
+	}
+
+	if query := ctx.URI().QueryString(); len(query) > 0 {
+		path += "?" + string(query)
+	}
+
+	if r.rawPath {
+		// ctx.Redirect decodes the path, encoded slashes have to be kept
+		ctx.Response.Header.Set(fasthttp.HeaderLocation, path)
+		ctx.SetStatusCode(redirectCode(string(ctx.Method())))
+		return
+	}
+
+	ctx.Redirect(path, redirectCode(string(ctx.Method())))
+}
+
+// routeHandler returns route handler wrapped with middleware, path is empty for the root route
+func (r *fastHTTPRouter) routeHandler(handlers composedHandlers, root mux.Node, rt mux.Route, path string) fasthttp.RequestHandler {
+	if h, ok := handlers[rt.(*route)]; ok {
+		return h.(fasthttp.RequestHandler)
+	}
+
+	if !r.live.concurrent && r.middlewareCounter == 0 {
+		return rt.Handler().(fasthttp.RequestHandler)
+	}
+
+	// merge always allocates new collection, tree owned ones must not be sorted in place
+	allMiddleware := root.Middleware().Merge(matchTreeMiddleware(root, path)).Sort()
+
+	return allMiddleware.Compose(rt.Handler()).(fasthttp.RequestHandler)
+}
+
+func (r *fastHTTPRouter) serveError(ctx *fasthttp.RequestCtx, err error) {
+	if s := r.live.loadSettings().(*fastHTTPSettings); s.errorHandler != nil {
+		s.errorHandler(ctx, err)
+	} else {
+		DefaultFastHTTPErrorHandler(ctx, err)
+	}
+}
+
+func (s *fastHTTPSettings) serveNotFound(ctx *fasthttp.RequestCtx) {
+	if s.notFound != nil {
+		s.notFound(ctx)
 	} else {
 		ctx.Error(fasthttp.StatusMessage(fasthttp.StatusNotFound), fasthttp.StatusNotFound)
 	}
 }
 
-func (r *fastHTTPRouter) serveNotAllowed(ctx *fasthttp.RequestCtx) {
-	if r.notAllowed != nil {
-		r.notAllowed(ctx)
+func (s *fastHTTPSettings) serveNotAllowed(ctx *fasthttp.RequestCtx) {
+	if s.notAllowed != nil {
+		s.notAllowed(ctx)
 	} else {
-		ctx.Error(fasthttp.StatusMessage(fasthttp.StatusMethodNotAllowed), fasthttp.StatusMethodNotAllowed)
+		// ctx.Error resets response headers, Allow header has to be kept
+		ctx.SetStatusCode(fasthttp.StatusMethodNotAllowed)
+		ctx.SetContentType("text/plain; charset=utf-8")
+		ctx.SetBodyString(fasthttp.StatusMessage(fasthttp.StatusMethodNotAllowed))
+	}
+}
+
+// newFastHTTPPanicRecoverer recovers handler panics replying to the request with panic handler
+func newFastHTTPPanicRecoverer(next fasthttp.RequestHandler, panicHandler func(*fasthttp.RequestCtx, interface{})) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		defer func() {
+			if rcv := recover(); rcv != nil {
+				panicHandler(ctx, rcv)
+			}
+		}()
+
+		next(ctx)
 	}
 }
 
@@ -1,10 +1,12 @@
 package gorouter
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/valyala/fasthttp"
@@ -24,6 +26,68 @@ func TestFastHTTPInterface(t *testing.T) {
 	var _ fasthttp.RequestHandler = NewFastHTTPRouter().HandleFastHTTP
 }
 
+func TestNewFastHTTPRouterWithOptions(t *testing.T) {
+	t.Parallel()
+
+	router := NewFastHTTPRouterWithOptions(
+		WithFastHTTPMiddleware(mockFastHTTPMiddleware("m1"), mockFastHTTPMiddleware("m2")),
+		WithFastHTTPNotFound(func(ctx *fasthttp.RequestCtx) {
+			ctx.SetStatusCode(fasthttp.StatusTeapot)
+		}),
+		WithFastHTTPNotAllowed(func(ctx *fasthttp.RequestCtx) {
+			ctx.SetStatusCode(fasthttp.StatusConflict)
+		}),
+		WithFastHTTPPanicHandler(func(ctx *fasthttp.RequestCtx, rcv interface{}) {
+			ctx.SetStatusCode(fasthttp.StatusInternalServerError)
+			if _, err := fmt.Fprint(ctx, rcv); err != nil {
+				t.Fatal(err)
+			}
+		}),
+		WithTrailingSlash(TrailingSlashStrict),
+		WithHeadFallback(false),
+		WithAutoOptions(false),
+	).(*fastHTTPRouter)
+
+	router.GET("/x", func(ctx *fasthttp.RequestCtx) {
+		if _, err := fmt.Fprint(ctx, "x"); err != nil {
+			t.Fatal(err)
+		}
+	})
+	router.GET("/panic", func(_ *fasthttp.RequestCtx) {
+		panic("oops")
+	})
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		code   int
+		body   string
+	}{
+		{"middleware", fasthttp.MethodGet, "/x", fasthttp.StatusOK, "m1m2x"},
+		{"not found", fasthttp.MethodGet, "/y", fasthttp.StatusTeapot, "m1m2"},
+		{"not allowed", fasthttp.MethodPost, "/x", fasthttp.StatusConflict, "m1m2"},
+		{"trailing slash", fasthttp.MethodGet, "/x/", fasthttp.StatusTeapot, "m1m2"},
+		{"head fallback", fasthttp.MethodHead, "/x", fasthttp.StatusConflict, "m1m2"},
+		{"auto options", fasthttp.MethodOptions, "/x", fasthttp.StatusConflict, "m1m2"},
+		{"panic", fasthttp.MethodGet, "/panic", fasthttp.StatusInternalServerError, "m1m2oops"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := buildFastHTTPRequestContext(tt.method, tt.path)
+
+			router.HandleFastHTTP(ctx)
+
+			if ctx.Response.StatusCode() != tt.code {
+				t.Errorf("%s %s: status code = %d, want %d", tt.method, tt.path, ctx.Response.StatusCode(), tt.code)
+			}
+			if string(ctx.Response.Body()) != tt.body {
+				t.Errorf("%s %s: body = %q, want %q", tt.method, tt.path, string(ctx.Response.Body()), tt.body)
+			}
+		})
+	}
+}
+
 func TestFastHTTPHandle(t *testing.T) {
 	t.Parallel()
 
@@ -69,6 +133,114 @@ func TestFastHTTPOPTIONSHeaders(t *testing.T) {
 	}
 }
 
+func TestFastHTTPAutoOptionsHandler(t *testing.T) {
+	t.Parallel()
+
+	var allow string
+	router := NewFastHTTPRouterWithOptions(WithFastHTTPAutoOptionsHandler(func(ctx *fasthttp.RequestCtx) {
+		allow = string(ctx.Response.Header.Peek("Allow"))
+		ctx.SetStatusCode(fasthttp.StatusNoContent)
+	})).(*fastHTTPRouter)
+
+	router.GET("/x", (&mockHandler{}).HandleFastHTTP)
+	router.OPTIONS("/y", func(ctx *fasthttp.RequestCtx) {
+		ctx.SetStatusCode(fasthttp.StatusAccepted)
+	})
+
+	ctx := buildFastHTTPRequestContext(fasthttp.MethodOptions, "/x")
+	router.HandleFastHTTP(ctx)
+
+	if ctx.Response.StatusCode() != fasthttp.StatusNoContent || allow != "GET, HEAD, OPTIONS" {
+		t.Errorf("expected automatic OPTIONS handler to be called with Allow header, got %d %q", ctx.Response.StatusCode(), allow)
+	}
+
+	ctx = buildFastHTTPRequestContext(fasthttp.MethodOptions, "/y")
+	router.HandleFastHTTP(ctx)
+
+	if ctx.Response.StatusCode() != fasthttp.StatusAccepted {
+		t.Errorf("expected OPTIONS route to be served, got %d", ctx.Response.StatusCode())
+	}
+
+	router.AutoOptions(nil)
+
+	ctx = buildFastHTTPRequestContext(fasthttp.MethodOptions, "/x")
+	router.HandleFastHTTP(ctx)
+
+	if ctx.Response.StatusCode() != fasthttp.StatusOK {
+		t.Errorf("expected empty reply without automatic OPTIONS handler, got %d", ctx.Response.StatusCode())
+	}
+}
+
+func TestFastHTTPRawPath(t *testing.T) {
+	t.Parallel()
+
+	var got context.Params
+	handler := func(ctx *fasthttp.RequestCtx) {
+		got, _ = ctx.UserValue("params").(context.Params)
+	}
+
+	router := NewFastHTTPRouterWithOptions(WithRawPath(true), WithTrailingSlash(TrailingSlashRedirect)).(*fastHTTPRouter)
+	router.GET("/files/{id}/meta", handler)
+	router.GET("/café/{name}", handler)
+	router.GET("/all/{path*}", handler)
+
+	subrouter := NewFastHTTPRouterWithOptions(WithRawPath(true))
+	subrouter.GET("/files/{id}", handler)
+	router.Mount("/v1", subrouter.HandleFastHTTP)
+
+	tests := []struct {
+		name     string
+		path     string
+		code     int
+		location string
+		params   context.Params
+	}{
+		{"encoded slash", "/files/a%2Fb/meta", fasthttp.StatusOK, "", context.Params{{Key: "id", Value: "a/b"}}},
+		{"encoded percent", "/files/100%25/meta", fasthttp.StatusOK, "", context.Params{{Key: "id", Value: "100%"}}},
+		{"double encoded slash", "/files/a%252Fb/meta", fasthttp.StatusOK, "", context.Params{{Key: "id", Value: "a%2Fb"}}},
+		{"unicode", "/caf%C3%A9/%E2%9C%93", fasthttp.StatusOK, "", context.Params{{Key: "name", Value: "✓"}}},
+		{"catch-all", "/all/a%2Fb/c", fasthttp.StatusOK, "", context.Params{{Key: "path", Value: "a/b/c"}}},
+		{"mounted", "/v1/files/a%2Fb", fasthttp.StatusOK, "", context.Params{{Key: "id", Value: "a/b"}}},
+		{"trailing slash redirect", "/files/a%2Fb/meta/", fasthttp.StatusMovedPermanently, "/files/a%2Fb/meta", nil},
+		{"not matching decoded slash", "/files/a/b/meta", fasthttp.StatusNotFound, "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = nil
+			ctx := buildFastHTTPRequestContext(fasthttp.MethodGet, tt.path)
+
+			router.HandleFastHTTP(ctx)
+
+			if ctx.Response.StatusCode() != tt.code {
+				t.Errorf("expected code %d, got %d", tt.code, ctx.Response.StatusCode())
+			}
+			if location := string(ctx.Response.Header.Peek("Location")); location != tt.location {
+				t.Errorf("expected location %q, got %q", tt.location, location)
+			}
+			if !reflect.DeepEqual(got, tt.params) {
+				t.Errorf("expected params %v, got %v", tt.params, got)
+			}
+		})
+	}
+
+	// decoded path is matched by default, encoded slash splits path parts
+	decoded := NewFastHTTPRouter()
+	decoded.GET("/files/{id}/meta", handler)
+	decoded.GET("/files/{id}/{name}/meta", handler)
+
+	got = nil
+	ctx := buildFastHTTPRequestContext(fasthttp.MethodGet, "/files/a%2Fb/meta")
+	decoded.HandleFastHTTP(ctx)
+
+	if ctx.Response.StatusCode() != fasthttp.StatusOK {
+		t.Errorf("expected code %d without raw path, got %d", fasthttp.StatusOK, ctx.Response.StatusCode())
+	}
+	if want := (context.Params{{Key: "id", Value: "a"}, {Key: "name", Value: "b"}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected params %v without raw path, got %v", want, got)
+	}
+}
+
 func TestFastHTTPMethods(t *testing.T) {
 	t.Parallel()
 
@@ -83,6 +255,7 @@ func TestFastHTTPMethods(t *testing.T) {
 		fasthttp.MethodTrace,
 		fasthttp.MethodOptions,
 	} {
+		method := method
 		t.Run(method, func(t *testing.T) {
 			t.Parallel()
 
@@ -127,7 +300,7 @@ func TestFastHTTPNotFound(t *testing.T) {
 		}
 	})
 
-	if router.notFound == nil {
+	if router.live.settings.(*fastHTTPSettings).notFound == nil {
 		t.Error("NotFound handler error")
 	}
 
@@ -161,7 +334,7 @@ func TestFastHTTPNotAllowed(t *testing.T) {
 		}
 	})
 
-	if router.notAllowed == nil {
+	if router.live.settings.(*fastHTTPSettings).notAllowed == nil {
 		t.Error("NotAllowed handler error")
 	}
 
@@ -182,6 +355,334 @@ func TestFastHTTPNotAllowed(t *testing.T) {
 	}
 }
 
+func TestFastHTTPHeadFallback(t *testing.T) {
+	t.Parallel()
+
+	router := NewFastHTTPRouter().(*fastHTTPRouter)
+	router.GET("/x/y", func(ctx *fasthttp.RequestCtx) {
+		ctx.Response.Header.Set("X-Test", "get")
+		if _, err := fmt.Fprintf(ctx, "body"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	ctx := buildFastHTTPRequestContext(fasthttp.MethodHead, "/x/y")
+
+	router.HandleFastHTTP(ctx)
+
+	if ctx.Response.StatusCode() != fasthttp.StatusOK {
+		t.Errorf("HEAD request should be served by GET route, got status %d", ctx.Response.StatusCode())
+	}
+	if string(ctx.Response.Header.Peek("X-Test")) != "get" {
+		t.Error("GET handler wasn't invoked")
+	}
+	if !ctx.Response.SkipBody {
+		t.Error("HEAD response should skip body")
+	}
+
+	ctx = buildFastHTTPRequestContext(fasthttp.MethodPost, "/x/y")
+
+	router.HandleFastHTTP(ctx)
+
+	if allow := string(ctx.Response.Header.Peek("Allow")); allow != "GET, HEAD, OPTIONS" {
+		t.Errorf("Allow header should include HEAD, got %q", allow)
+	}
+
+	router.headFallback = false
+
+	ctx = buildFastHTTPRequestContext(fasthttp.MethodHead, "/x/y")
+
+	router.HandleFastHTTP(ctx)
+
+	if ctx.Response.StatusCode() != fasthttp.StatusMethodNotAllowed {
+		t.Errorf("HEAD request should not be allowed, got status %d", ctx.Response.StatusCode())
+	}
+	if allow := string(ctx.Response.Header.Peek("Allow")); allow != "GET, OPTIONS" {
+		t.Errorf("Allow header should not include HEAD, got %q", allow)
+	}
+}
+
+func TestFastHTTPTrailingSlash(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		policy   TrailingSlashPolicy
+		method   string
+		path     string
+		code     int
+		location string
+	}{
+		{"lenient", TrailingSlashLenient, fasthttp.MethodGet, "/users/", fasthttp.StatusOK, ""},
+		{"lenient with slash", TrailingSlashLenient, fasthttp.MethodGet, "/docs", fasthttp.StatusOK, ""},
+		{"strict", TrailingSlashStrict, fasthttp.MethodGet, "/users", fasthttp.StatusOK, ""},
+		{"strict not found", TrailingSlashStrict, fasthttp.MethodGet, "/users/", fasthttp.StatusNotFound, ""},
+		{"strict with slash", TrailingSlashStrict, fasthttp.MethodGet, "/docs/", fasthttp.StatusOK, ""},
+		{"strict with slash not found", TrailingSlashStrict, fasthttp.MethodPost, "/docs", fasthttp.StatusNotFound, ""},
+		{"strict root", TrailingSlashStrict, fasthttp.MethodGet, "/", fasthttp.StatusOK, ""},
+		{"strict mount", TrailingSlashStrict, fasthttp.MethodGet, "/mount/x/", fasthttp.StatusOK, ""},
+		{"redirect", TrailingSlashRedirect, fasthttp.MethodGet, "/users/", fasthttp.StatusMovedPermanently, "/users"},
+		{"redirect with slash", TrailingSlashRedirect, fasthttp.MethodGet, "/docs", fasthttp.StatusMovedPermanently, "/docs/"},
+		{"redirect post", TrailingSlashRedirect, fasthttp.MethodPost, "/docs", fasthttp.StatusPermanentRedirect, "/docs/"},
+		{"redirect canonical", TrailingSlashRedirect, fasthttp.MethodGet, "/users", fasthttp.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := func(ctx *fasthttp.RequestCtx) {}
+
+			router := NewFastHTTPRouter().(*fastHTTPRouter)
+			router.TrailingSlash(tt.policy)
+			router.GET("/", handler)
+			router.GET("/users", handler)
+			router.GET("/docs/", handler)
+			router.POST("/docs/", handler)
+			router.Mount("/mount", handler)
+
+			ctx := buildFastHTTPRequestContext(tt.method, tt.path)
+
+			router.HandleFastHTTP(ctx)
+
+			if ctx.Response.StatusCode() != tt.code {
+				t.Errorf("%s %s: status code = %d, want %d", tt.method, tt.path, ctx.Response.StatusCode(), tt.code)
+			}
+			if location := string(ctx.Response.Header.Peek("Location")); !strings.HasSuffix(location, tt.location) || (tt.location == "") != (location == "") {
+				t.Errorf("%s %s: location = %q, want %q", tt.method, tt.path, location, tt.location)
+			}
+		})
+	}
+}
+
+func TestFastHTTPRedirectCleanPath(t *testing.T) {
+	t.Parallel()
+
+	router := NewFastHTTPRouter().(*fastHTTPRouter)
+	router.GET("/a/c", func(ctx *fasthttp.RequestCtx) {})
+	router.RedirectCleanPath(true)
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(fasthttp.MethodGet)
+	ctx.Request.SetRequestURI("/a//b/../c?x=1")
+
+	router.HandleFastHTTP(ctx)
+
+	if ctx.Response.StatusCode() != fasthttp.StatusMovedPermanently {
+		t.Errorf("Not clean path should be redirected, got status code %d", ctx.Response.StatusCode())
+	}
+	if location := string(ctx.Response.Header.Peek("Location")); !strings.HasSuffix(location, "/a/c?x=1") {
+		t.Errorf("Location = %q, want suffix %q", location, "/a/c?x=1")
+	}
+}
+
+func TestFastHTTPCaseInsensitive(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		redirect bool
+		path     string
+		code     int
+		body     string
+		location string
+	}{
+		{"registered casing", false, "/users/John", fasthttp.StatusOK, "John", ""},
+		{"different casing", false, "/USERS/John", fasthttp.StatusOK, "John", ""},
+		{"redirect registered casing", true, "/users/John", fasthttp.StatusOK, "John", ""},
+		{"redirect different casing", true, "/Users/John", fasthttp.StatusMovedPermanently, "", "/users/John"},
+		{"redirect trailing slash", true, "/USERS/John/", fasthttp.StatusMovedPermanently, "", "/users/John/"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			router := NewFastHTTPRouter().(*fastHTTPRouter)
+			router.GET("/users/{name}", func(ctx *fasthttp.RequestCtx) {
+				params := ctx.UserValue("params").(context.Params)
+				if _, err := fmt.Fprint(ctx, params.Value("name")); err != nil {
+					t.Fatal(err)
+				}
+			})
+			router.CaseInsensitive(tt.redirect)
+
+			ctx := buildFastHTTPRequestContext(fasthttp.MethodGet, tt.path)
+
+			router.HandleFastHTTP(ctx)
+
+			if ctx.Response.StatusCode() != tt.code {
+				t.Errorf("%s: status code = %d, want %d", tt.path, ctx.Response.StatusCode(), tt.code)
+			}
+			if tt.body != "" && string(ctx.Response.Body()) != tt.body {
+				t.Errorf("%s: body = %q, want %q", tt.path, string(ctx.Response.Body()), tt.body)
+			}
+			if location := string(ctx.Response.Header.Peek("Location")); !strings.HasSuffix(location, tt.location) || (tt.location == "") != (location == "") {
+				t.Errorf("%s: location = %q, want %q", tt.path, location, tt.location)
+			}
+		})
+	}
+}
+
+func TestFastHTTPRoutePattern(t *testing.T) {
+	t.Parallel()
+
+	var got context.Route
+
+	router := NewFastHTTPRouterWithOptions(WithRoutePattern(true)).(*fastHTTPRouter)
+	router.Group("/users", func(g FastHTTPRouteGroup) {
+		g.GET("/{id}", func(ctx *fasthttp.RequestCtx) {
+			params := ctx.UserValue("params").(context.Params)
+			if params.Value("id") != "42" {
+				t.Errorf("Wrong params value, got %s", params.Value("id"))
+			}
+		})
+	})
+	router.USE(fasthttp.MethodGet, "/users", func(h fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			got, _ = FastHTTPRoutePattern(ctx)
+			h(ctx)
+		}
+	})
+	router.GET("/", func(ctx *fasthttp.RequestCtx) {
+		got, _ = FastHTTPRoutePattern(ctx)
+	})
+
+	tests := []struct {
+		method string
+		path   string
+		want   context.Route
+	}{
+		{fasthttp.MethodGet, "/users/42", context.Route{Method: fasthttp.MethodGet, Pattern: "/users/{id}"}},
+		{fasthttp.MethodHead, "/users/42", context.Route{Method: fasthttp.MethodGet, Pattern: "/users/{id}"}},
+		{fasthttp.MethodGet, "/", context.Route{Method: fasthttp.MethodGet, Pattern: "/"}},
+	}
+	for _, tt := range tests {
+		got = context.Route{}
+
+		router.HandleFastHTTP(buildFastHTTPRequestContext(tt.method, tt.path))
+
+		if got != tt.want {
+			t.Errorf("%s %s: route pattern = %v, want %v", tt.method, tt.path, got, tt.want)
+		}
+	}
+
+	withoutPattern := NewFastHTTPRouter()
+	withoutPattern.GET("/", func(ctx *fasthttp.RequestCtx) {
+		if _, ok := FastHTTPRoutePattern(ctx); ok {
+			t.Error("Route pattern should not be stored without option")
+		}
+	})
+
+	withoutPattern.HandleFastHTTP(buildFastHTTPRequestContext(fasthttp.MethodGet, "/"))
+}
+
+func TestFastHTTPHost(t *testing.T) {
+	t.Parallel()
+
+	write := func(body string) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			params, _ := ctx.UserValue("params").(context.Params)
+			if _, err := fmt.Fprintf(ctx, "%s:%s:%s", body, params.Value("tenant"), params.Value("id")); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	router := NewFastHTTPRouter(mockFastHTTPMiddleware("g"))
+	router.GET("/users/{id}", write("default"))
+	router.Host("{tenant}.example.com").GET("/users/{id}", write("tenant"))
+	router.Host("api.example.com").GET("/users/{id}", write("api"))
+	router.Host("{lang:[a-z]{2}}.EXAMPLE.org").GET("/", write("org"))
+
+	if router.Host("api.example.com") != router.Host("api.example.com") {
+		t.Error("Host should return the same router for the same pattern")
+	}
+
+	router.Compile()
+
+	tests := []struct {
+		host string
+		path string
+		code int
+		body string
+	}{
+		{"acme.example.com", "/users/1", fasthttp.StatusOK, "gtenant:acme:1"},
+		{"ACME.example.com:8080", "/users/1", fasthttp.StatusOK, "gtenant:acme:1"},
+		{"api.example.com", "/users/1", fasthttp.StatusOK, "gapi::1"},
+		{"example.com", "/users/1", fasthttp.StatusOK, "gdefault::1"},
+		{"a.b.example.com", "/users/1", fasthttp.StatusOK, "gdefault::1"},
+		{"pl.example.org", "/", fasthttp.StatusOK, "gorg::"},
+		{"pol.example.org", "/", fasthttp.StatusNotFound, ""},
+		{"acme.example.com", "/posts", fasthttp.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		ctx := buildFastHTTPRequestContext(fasthttp.MethodGet, tt.path)
+		ctx.URI().SetHost(tt.host)
+
+		router.HandleFastHTTP(ctx)
+
+		if ctx.Response.StatusCode() != tt.code {
+			t.Errorf("%s%s: status code = %d, want %d", tt.host, tt.path, ctx.Response.StatusCode(), tt.code)
+		}
+		if tt.body != "" && string(ctx.Response.Body()) != tt.body {
+			t.Errorf("%s%s: body = %q, want %q", tt.host, tt.path, string(ctx.Response.Body()), tt.body)
+		}
+	}
+}
+
+func TestFastHTTPRoutes(t *testing.T) {
+	t.Parallel()
+
+	handler := &mockHandler{}
+	router := NewFastHTTPRouter(mockFastHTTPMiddleware("global"))
+	router.GET("/", handler.HandleFastHTTP, "home")
+	router.Group("/users", func(g FastHTTPRouteGroup) {
+		g.USE(fasthttp.MethodGet, "/", mockFastHTTPMiddleware("users"))
+		g.GET("/{id:int}", handler.HandleFastHTTP, "user")
+		g.POST("/{id}/posts/", handler.HandleFastHTTP)
+	})
+	router.Mount("/files", handler.HandleFastHTTP)
+	router.GET("/reports/{year?:[0-9]+}/{month?}", handler.HandleFastHTTP)
+	router.PUT("/{page?}", handler.HandleFastHTTP)
+	router.Host("{tenant}.example.com").GET("/{slug:[a-z]+}", handler.HandleFastHTTP)
+
+	routes := router.Routes()
+
+	want := []RouteInfo{
+		{Method: fasthttp.MethodGet, Pattern: "/", Name: "home"},
+		{Method: fasthttp.MethodGet, Pattern: "/users/{id:int}", Name: "user", Params: []string{"id"}, Constraints: map[string]string{"id": "int"}, Middleware: 1},
+		{Method: fasthttp.MethodPost, Pattern: "/users/{id}/posts/", Params: []string{"id"}},
+		{Method: fasthttp.MethodGet, Pattern: "/reports/{year?:[0-9]+}/{month?}", Params: []string{"year", "month"}, Constraints: map[string]string{"year": "[0-9]+"}},
+		{Method: fasthttp.MethodPut, Pattern: "/{page?}", Params: []string{"page"}},
+	}
+	for _, method := range allFasthttpMethods {
+		want = append(want, RouteInfo{Method: method, Pattern: "/files", Subrouter: true})
+	}
+	want = append(want, RouteInfo{Method: fasthttp.MethodGet, Host: "{tenant}.example.com", Pattern: "/{slug:[a-z]+}", Params: []string{"slug"}, Constraints: map[string]string{"slug": "[a-z]+"}})
+
+	if len(routes) != len(want) {
+		t.Fatalf("expected %d routes, got %d: %+v", len(want), len(routes), routes)
+	}
+
+	for _, info := range want {
+		found := false
+		for _, got := range routes {
+			if reflect.DeepEqual(got, info) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("route %+v not found in %+v", info, routes)
+		}
+	}
+
+	if last := routes[len(routes)-1]; last.Host == "" {
+		t.Errorf("host routes should be listed last, got %+v", last)
+	}
+}
+
 func TestFastHTTPParam(t *testing.T) {
 	t.Parallel()
 
@@ -207,6 +708,37 @@ func TestFastHTTPParam(t *testing.T) {
 	}
 }
 
+func TestFastHTTPConstraintParam(t *testing.T) {
+	t.Parallel()
+
+	router := NewFastHTTPRouter().(*fastHTTPRouter)
+	router.GET("/x/{id:int}", func(ctx *fasthttp.RequestCtx) {
+		params := ctx.UserValue("params").(context.Params)
+		if _, err := fmt.Fprint(ctx, "int:"+params.Value("id")); err != nil {
+			t.Fatal(err)
+		}
+	})
+	router.GET("/x/{slug}", func(ctx *fasthttp.RequestCtx) {
+		params := ctx.UserValue("params").(context.Params)
+		if _, err := fmt.Fprint(ctx, "slug:"+params.Value("slug")); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	for path, want := range map[string]string{
+		"/x/42":    "int:42",
+		"/x/hello": "slug:hello",
+	} {
+		ctx := buildFastHTTPRequestContext(fasthttp.MethodGet, path)
+
+		router.HandleFastHTTP(ctx)
+
+		if string(ctx.Response.Body()) != want {
+			t.Errorf("%s: body = %q, want %q", path, string(ctx.Response.Body()), want)
+		}
+	}
+}
+
 func TestFastHTTPRegexpParam(t *testing.T) {
 	t.Parallel()
 
@@ -259,7 +791,7 @@ func TestFastHTTPServeFiles(t *testing.T) {
 
 	router.ServeFiles("/var/www/static", 1)
 
-	if router.fileServer == nil {
+	if router.live.settings.(*fastHTTPSettings).fileServer == nil {
 		t.Error("File server handler error")
 	}
 	var ctx fasthttp.RequestCtx
@@ -666,3 +1198,1019 @@ func TestFastHTTPMountSubRouter_third(t *testing.T) {
 		t.Errorf("subrouter route did not match: %s", ctx.Response.Body())
 	}
 }
+
+func TestFastHTTPURL(t *testing.T) {
+	t.Parallel()
+
+	handler := &mockHandler{}
+	router := NewFastHTTPRouter().(*fastHTTPRouter)
+
+	router.GET("/", handler.HandleFastHTTP, "home")
+	router.GET("/users/{id:[0-9]+}", handler.HandleFastHTTP, "user")
+	router.POST("/users/{id}/posts/{slug}", handler.HandleFastHTTP, "user_post")
+
+	tests := []struct {
+		name    string
+		route   string
+		params  []string
+		want    string
+		wantErr bool
+	}{
+		{"root", "home", nil, "/", false},
+		{"regexp", "user", []string{"id", "42"}, "/users/42", false},
+		{"wildcards", "user_post", []string{"id", "1", "slug", "hello"}, "/users/1/posts/hello", false},
+		{"escaped value", "user_post", []string{"id", "a/b?c#d", "slug", "hello world"}, "/users/a%2Fb%3Fc%23d/posts/hello%20world", false},
+		{"invalid regexp value", "user", []string{"id", "abc"}, "", true},
+		{"missing wildcard", "user_post", []string{"slug", "hello"}, "", true},
+		{"unknown param", "user", []string{"id", "42", "page", "2"}, "", true},
+		{"unknown route", "unknown", nil, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := router.URL(tt.route, tt.params...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("URL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("URL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFastHTTPTryHandle(t *testing.T) {
+	t.Parallel()
+
+	handler := (&mockHandler{}).HandleFastHTTP
+	router := NewFastHTTPRouter()
+
+	router.GET("/users/{id}", handler, "user")
+	router.GET("/posts/{slug:[a-z]+}", handler)
+	router.Mount("/files", handler)
+
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		routeName []string
+		existing  string
+	}{
+		{"new route", fasthttp.MethodGet, "/users/{id}/posts", nil, ""},
+		{"other method", fasthttp.MethodPost, "/users/{id}", nil, ""},
+		{"param referenced by name", fasthttp.MethodGet, "/posts/{slug}/comments", nil, ""},
+		{"duplicate route", fasthttp.MethodGet, "/users/{id}", nil, "GET /users/{id}"},
+		{"duplicate route with trailing slash", fasthttp.MethodGet, "/users/{id}/", nil, "GET /users/{id}"},
+		{"ambiguous wildcard", fasthttp.MethodGet, "/users/{name}", nil, "GET /users/{id}"},
+		{"different regexp", fasthttp.MethodGet, "/posts/{slug:[0-9]+}", nil, "GET /posts/{slug:[a-z]+}"},
+		{"shadowed by mounted handler", fasthttp.MethodGet, "/files/{name}", nil, "GET /files"},
+		{"duplicate name", fasthttp.MethodGet, "/other", []string{"user"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := router.TryHandle(tt.method, tt.path, handler, tt.routeName...)
+
+			if tt.existing == "" && tt.routeName == nil {
+				if err != nil {
+					t.Errorf("unexpected error %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if tt.existing == "" {
+				return
+			}
+
+			var conflict *ConflictError
+			if !errors.As(err, &conflict) {
+				t.Fatalf("expected conflict error, got %v", err)
+			}
+			if existing := conflict.ExistingMethod + " " + conflict.ExistingPattern; existing != tt.existing {
+				t.Errorf("expected conflict with %s, got %s", tt.existing, existing)
+			}
+			if !strings.Contains(err.Error(), tt.path) || !strings.Contains(err.Error(), conflict.ExistingPattern) {
+				t.Errorf("error %q should name both patterns", err)
+			}
+		})
+	}
+}
+
+func TestFastHTTPTryHandleMalformedPattern(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		path string
+	}{
+		{"catch-all followed by path part", "/c/{p*}/x"},
+		{"invalid regexp", "/{id:[}"},
+		{"template with adjacent params", "/{a}{b}"},
+		{"required part after optional one", "/{a?}/{b}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := NewFastHTTPRouter()
+
+			var err error
+			func() {
+				defer func() {
+					if rcv := recover(); rcv != nil {
+						t.Fatalf("TryHandle should not panic, got %v", rcv)
+					}
+				}()
+
+				err = router.TryHandle(fasthttp.MethodGet, tt.path, (&mockHandler{}).HandleFastHTTP)
+			}()
+
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if routes := router.Routes(); len(routes) != 0 {
+				t.Errorf("Malformed route should not be registered, got %v", routes)
+			}
+		})
+	}
+}
+
+func TestFastHTTPTryHandleCompiled(t *testing.T) {
+	t.Parallel()
+
+	router := NewFastHTTPRouter()
+	router.GET("/a/b/c", (&mockHandler{}).HandleFastHTTP)
+	router.Compile()
+
+	var conflict *ConflictError
+	if err := router.TryHandle(fasthttp.MethodGet, "/a/b/c", (&mockHandler{}).HandleFastHTTP); !errors.As(err, &conflict) {
+		t.Fatalf("expected conflict error, got %v", err)
+	}
+	if conflict.ExistingPattern != "/a/b/c" {
+		t.Errorf("expected conflict with /a/b/c, got %s", conflict.ExistingPattern)
+	}
+	if err := router.TryHandle(fasthttp.MethodGet, "/a/b/c/d", (&mockHandler{}).HandleFastHTTP); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if routes := router.Routes(); len(routes) != 2 {
+		t.Errorf("Routes should list every route once, got %v", routes)
+	}
+}
+
+func TestFastHTTPRouteConflictPanics(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		register func(r FastHTTPRouter)
+	}{
+		{"duplicate route", func(r FastHTTPRouter) {
+			r.GET("/x", (&mockHandler{}).HandleFastHTTP)
+			r.GET("/x", (&mockHandler{}).HandleFastHTTP)
+		}},
+		{"mount shadows route", func(r FastHTTPRouter) {
+			r.GET("/x/y", (&mockHandler{}).HandleFastHTTP)
+			r.Mount("/x", (&mockHandler{}).HandleFastHTTP)
+		}},
+		{"ambiguous host", func(r FastHTTPRouter) {
+			r.Host("{a}.example.com").GET("/", (&mockHandler{}).HandleFastHTTP)
+			r.Host("{b}.example.com")
+		}},
+		{"host with port", func(r FastHTTPRouter) {
+			r.Host("api.example.com:8080")
+		}},
+		{"group duplicate route", func(r FastHTTPRouter) {
+			r.GET("/v1/x", (&mockHandler{}).HandleFastHTTP)
+			r.Group("/v1", func(g FastHTTPRouteGroup) {
+				g.GET("/x", (&mockHandler{}).HandleFastHTTP)
+			})
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if rcv := recover(); rcv == nil {
+					t.Error("Router should panic for conflicting registration")
+				}
+			}()
+
+			tt.register(NewFastHTTPRouter())
+		})
+	}
+}
+
+func TestFastHTTPHandleE(t *testing.T) {
+	t.Parallel()
+
+	router := NewFastHTTPRouter().(*fastHTTPRouter)
+
+	router.HandleE(fasthttp.MethodGet, "/ok", func(ctx *fasthttp.RequestCtx) error {
+		ctx.SetBodyString("ok")
+		return nil
+	})
+	router.HandleE(fasthttp.MethodGet, "/teapot", func(_ *fasthttp.RequestCtx) error {
+		return fmt.Errorf("wrapped: %w", mockHTTPError{fasthttp.StatusTeapot})
+	})
+	router.HandleE(fasthttp.MethodGet, "/users/{id}", func(ctx *fasthttp.RequestCtx) error {
+		params := ctx.UserValue("params").(context.Params)
+		_, err := params.Int("id")
+		return err
+	})
+	router.HandleE(fasthttp.MethodGet, "/internal", func(_ *fasthttp.RequestCtx) error {
+		return errors.New("secret")
+	})
+	router.HandleE(fasthttp.MethodGet, "/panic", func(_ *fasthttp.RequestCtx) error {
+		panic("boom")
+	})
+
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/ok", fasthttp.StatusOK, "ok"},
+		{"/teapot", fasthttp.StatusTeapot, "mock error 418"},
+		{"/users/x", fasthttp.StatusBadRequest, "param \"id\": invalid int value \"x\": invalid syntax"},
+		{"/internal", fasthttp.StatusInternalServerError, "Internal Server Error"},
+		{"/panic", fasthttp.StatusInternalServerError, "Internal Server Error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			ctx := buildFastHTTPRequestContext(fasthttp.MethodGet, tt.path)
+
+			router.HandleFastHTTP(ctx)
+
+			if ctx.Response.StatusCode() != tt.code {
+				t.Errorf("expected code %d, got %d", tt.code, ctx.Response.StatusCode())
+			}
+			if string(ctx.Response.Body()) != tt.body {
+				t.Errorf("expected body %q, got %q", tt.body, ctx.Response.Body())
+			}
+		})
+	}
+}
+
+func TestFastHTTPOnError(t *testing.T) {
+	t.Parallel()
+
+	var handled error
+	router := NewFastHTTPRouterWithOptions(WithFastHTTPErrorHandler(func(ctx *fasthttp.RequestCtx, _ error) {
+		ctx.SetStatusCode(fasthttp.StatusServiceUnavailable)
+	})).(*fastHTTPRouter)
+
+	router.Group("/v1", func(g FastHTTPRouteGroup) {
+		g.HandleE(fasthttp.MethodGet, "/panic", func(_ *fasthttp.RequestCtx) error {
+			panic("boom")
+		})
+	})
+
+	router.OnError(func(ctx *fasthttp.RequestCtx, err error) {
+		handled = err
+		ctx.SetStatusCode(fasthttp.StatusBadGateway)
+	})
+
+	ctx := buildFastHTTPRequestContext(fasthttp.MethodGet, "/v1/panic")
+	router.HandleFastHTTP(ctx)
+
+	if ctx.Response.StatusCode() != fasthttp.StatusBadGateway {
+		t.Errorf("expected code %d, got %d", fasthttp.StatusBadGateway, ctx.Response.StatusCode())
+	}
+
+	var panicErr *PanicError
+	if !errors.As(handled, &panicErr) || panicErr.Value != "boom" {
+		t.Errorf("expected recovered panic error, got %v", handled)
+	}
+
+	// error handler covers HandleE handlers only
+	handled = nil
+	router.GET("/users/{id:[0-9]+}", (&mockHandler{}).HandleFastHTTP)
+
+	ctx = buildFastHTTPRequestContext(fasthttp.MethodGet, "/users/x")
+	router.HandleFastHTTP(ctx)
+
+	if ctx.Response.StatusCode() != fasthttp.StatusNotFound || handled != nil {
+		t.Errorf("expected not matching param to be replied with the 404 Error code, got %d and %v", ctx.Response.StatusCode(), handled)
+	}
+}
+
+func TestFastHTTPGroup(t *testing.T) {
+	t.Parallel()
+
+	router := NewFastHTTPRouter().(*fastHTTPRouter)
+
+	router.Group("/api", func(api FastHTTPRouteGroup) {
+		api.USEANY("/", mockFastHTTPMiddleware("[api]"))
+
+		api.Group("/v1", func(v1 FastHTTPRouteGroup) {
+			v1.USE(fasthttp.MethodGet, "/users", mockFastHTTPMiddleware("[v1]"))
+
+			v1.GET("/users/{id}", func(ctx *fasthttp.RequestCtx) {
+				params := ctx.UserValue("params").(context.Params)
+				_, _ = fmt.Fprintf(ctx, "user %s", params.Value("id"))
+			})
+		})
+
+		api.POST("/status", func(ctx *fasthttp.RequestCtx) {
+			_, _ = fmt.Fprint(ctx, "status")
+		})
+	})
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{fasthttp.MethodGet, "/api/v1/users/1", "[api][v1]user 1"},
+		{fasthttp.MethodPost, "/api/status", "[api]status"},
+	}
+	for _, tt := range tests {
+		ctx := buildFastHTTPRequestContext(tt.method, tt.path)
+
+		router.HandleFastHTTP(ctx)
+
+		if string(ctx.Response.Body()) != tt.body {
+			t.Errorf("%s %s: expected %s, got %s", tt.method, tt.path, tt.body, ctx.Response.Body())
+		}
+	}
+}
+
+func TestFastHTTPCatchAllParam(t *testing.T) {
+	t.Parallel()
+
+	router := NewFastHTTPRouter().(*fastHTTPRouter)
+
+	router.GET("/files/{path*}", func(ctx *fasthttp.RequestCtx) {
+		params := ctx.UserValue("params").(context.Params)
+		_, _ = fmt.Fprint(ctx, params.Value("path"))
+	})
+
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/files/a.txt", fasthttp.StatusOK, "a.txt"},
+		{"/files/a/b/c.txt", fasthttp.StatusOK, "a/b/c.txt"},
+		{"/files", fasthttp.StatusNotFound, fasthttp.StatusMessage(fasthttp.StatusNotFound)},
+	}
+	for _, tt := range tests {
+		ctx := buildFastHTTPRequestContext(fasthttp.MethodGet, tt.path)
+
+		router.HandleFastHTTP(ctx)
+
+		if ctx.Response.StatusCode() != tt.code || string(ctx.Response.Body()) != tt.body {
+			t.Errorf("%s: expected %d %q, got %d %q", tt.path, tt.code, tt.body, ctx.Response.StatusCode(), ctx.Response.Body())
+		}
+	}
+}
+
+func TestFastHTTPRegexpParamFullSegmentMatch(t *testing.T) {
+	t.Parallel()
+
+	router := NewFastHTTPRouter().(*fastHTTPRouter)
+
+	router.GET("/x/{id:[0-9]+}", func(ctx *fasthttp.RequestCtx) {
+		params := ctx.UserValue("params").(context.Params)
+		_, _ = fmt.Fprint(ctx, params.Value("id"))
+	})
+	router.GET("/y/{id:~[0-9]+}", func(ctx *fasthttp.RequestCtx) {
+		params := ctx.UserValue("params").(context.Params)
+		_, _ = fmt.Fprint(ctx, params.Value("id"))
+	})
+
+	tests := []struct {
+		name string
+		path string
+		code int
+	}{
+		{"anchored match", "/x/123", fasthttp.StatusOK},
+		{"anchored no match", "/x/abc1def", fasthttp.StatusNotFound},
+		{"anchored prefix no match", "/x/1abc", fasthttp.StatusNotFound},
+		{"partial match", "/y/abc1def", fasthttp.StatusOK},
+		{"partial no match", "/y/abc", fasthttp.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := buildFastHTTPRequestContext(fasthttp.MethodGet, tt.path)
+
+			router.HandleFastHTTP(ctx)
+
+			if ctx.Response.StatusCode() != tt.code {
+				t.Errorf("%s: expected %d, got %d", tt.path, tt.code, ctx.Response.StatusCode())
+			}
+		})
+	}
+}
+
+func TestFastHTTPCompiledMiddleware(t *testing.T) {
+	t.Parallel()
+
+	handler := func(body string) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			_, _ = fmt.Fprint(ctx, body)
+		}
+	}
+
+	setup := func() *fastHTTPRouter {
+		r := NewFastHTTPRouter(mockFastHTTPMiddleware("[g]")).(*fastHTTPRouter)
+
+		r.GET("/", handler("root"))
+		r.GET("/x/{param}", handler("param"))
+		r.GET("/x/y/z", handler("xyz"))
+
+		r.USE(fasthttp.MethodGet, "/", mockFastHTTPMiddleware("[m]"))
+		r.USE(fasthttp.MethodGet, "/x/{param}", mockFastHTTPMiddleware("[param]"))
+		r.USE(fasthttp.MethodGet, "/x/x", mockFastHTTPMiddleware("[orphan]"))
+
+		return r
+	}
+
+	compiled := setup()
+	compiled.Compile()
+
+	tests := []struct {
+		path string
+		body string
+	}{
+		{"/", "[g][m]root"},
+		{"/x/y", "[g][m][param]param"},
+		{"/x/x", "[g][m][param][orphan]param"},
+		{"/x/y/z", "[g][m][param]xyz"},
+	}
+	for _, tt := range tests {
+		for name, r := range map[string]*fastHTTPRouter{"raw": setup(), "compiled": compiled} {
+			ctx := buildFastHTTPRequestContext(fasthttp.MethodGet, tt.path)
+
+			r.HandleFastHTTP(ctx)
+
+			if string(ctx.Response.Body()) != tt.body {
+				t.Errorf("%s %s: expected %s, got %s", name, tt.path, tt.body, ctx.Response.Body())
+			}
+		}
+	}
+}
+
+func TestFastHTTPRemove(t *testing.T) {
+	t.Parallel()
+
+	handler := func(ctx *fasthttp.RequestCtx) {
+		_, _ = fmt.Fprint(ctx, string(ctx.Path()))
+	}
+
+	router := NewFastHTTPRouter()
+	router.GET("/", handler)
+	router.GET("/users/{id}", handler, "user")
+	router.GET("/users/{id}/posts", handler)
+	router.GET("/a/c", handler)
+	router.GET("b//d", handler)
+	router.Mount("/files", handler)
+
+	var api FastHTTPRouteGroup
+	router.Group("/api", func(g FastHTTPRouteGroup) {
+		api = g
+		g.GET("/x", handler)
+	})
+
+	tests := []struct {
+		name   string
+		router interface {
+			Remove(method, pattern string) bool
+		}
+		method  string
+		pattern string
+		removed bool
+		path    string
+		code    int
+	}{
+		{"different pattern", router, fasthttp.MethodGet, "/users/{id:[0-9]+}", false, "/users/1", fasthttp.StatusOK},
+		{"other method", router, fasthttp.MethodPost, "/users/{id}", false, "/users/1", fasthttp.StatusOK},
+		{"route", router, fasthttp.MethodGet, "/users/{id}", true, "/users/1", fasthttp.StatusNotFound},
+		{"removed route", router, fasthttp.MethodGet, "/users/{id}", false, "/users/1", fasthttp.StatusNotFound},
+		{"pattern without leading slash", router, fasthttp.MethodGet, "a/c", true, "/a/c", fasthttp.StatusNotFound},
+		{"other trailing slash", router, fasthttp.MethodGet, "/b/d/", false, "/b/d", fasthttp.StatusOK},
+		{"not clean pattern", router, fasthttp.MethodGet, "/b/./d", true, "/b/d", fasthttp.StatusNotFound},
+		{"root route", router, fasthttp.MethodGet, "/", true, "/", fasthttp.StatusNotFound},
+		{"mounted handler method", router, fasthttp.MethodGet, "/files", true, "/files/a", fasthttp.StatusMethodNotAllowed},
+		{"group route", api, fasthttp.MethodGet, "/x", true, "/api/x", fasthttp.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if removed := tt.router.Remove(tt.method, tt.pattern); removed != tt.removed {
+				t.Errorf("expected removed %v, got %v", tt.removed, removed)
+			}
+
+			ctx := buildFastHTTPRequestContext(fasthttp.MethodGet, tt.path)
+			router.HandleFastHTTP(ctx)
+
+			if ctx.Response.StatusCode() != tt.code {
+				t.Errorf("%s: expected status %d, got %d", tt.path, tt.code, ctx.Response.StatusCode())
+			}
+		})
+	}
+
+	ctx := buildFastHTTPRequestContext(fasthttp.MethodGet, "/users/1/posts")
+	router.HandleFastHTTP(ctx)
+	if string(ctx.Response.Body()) != "/users/1/posts" {
+		t.Errorf("expected nested route to be kept, got %q", ctx.Response.Body())
+	}
+
+	if _, err := router.URL("user", "id", "1"); err == nil {
+		t.Error("expected removed route name to be unregistered")
+	}
+
+	if err := router.TryHandle(fasthttp.MethodGet, "/users/{id}", handler, "user"); err != nil {
+		t.Errorf("expected route to be registered again, got %v", err)
+	}
+}
+
+func TestFastHTTPConcurrentUpdates(t *testing.T) {
+	t.Parallel()
+
+	handler := func(ctx *fasthttp.RequestCtx) {
+		_, _ = fmt.Fprint(ctx, string(ctx.Path()))
+	}
+
+	router := NewFastHTTPRouterWithOptions(WithConcurrentUpdates(true))
+	router.USE(fasthttp.MethodGet, "/stable", mockFastHTTPMiddleware("[m]"))
+	router.GET("/stable", handler)
+	router.HandleE(fasthttp.MethodGet, "/teapot", func(_ *fasthttp.RequestCtx) error {
+		return errors.New("error")
+	})
+	router.Compile()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 100; i++ {
+			pattern := fmt.Sprintf("/flags/%d", i%5)
+
+			router.CaseInsensitive(i%2 == 0)
+			router.TrailingSlash(TrailingSlashPolicy(i % 3))
+			router.RedirectCleanPath(i%2 == 0)
+			router.NotFound(handler)
+			router.NotAllowed(handler)
+			router.OnError(func(ctx *fasthttp.RequestCtx, _ error) {
+				ctx.SetStatusCode(fasthttp.StatusTeapot)
+			})
+
+			router.Host(fmt.Sprintf("example%d.com", i)).GET(pattern, handler)
+			router.GET(pattern, handler, "flag")
+			router.USE(fasthttp.MethodGet, pattern, mockFastHTTPMiddleware("[f]"))
+			if !router.Remove(fasthttp.MethodGet, pattern) {
+				t.Errorf("expected %s to be removed", pattern)
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			for j := 0; j < 200; j++ {
+				ctx := buildFastHTTPRequestContext(fasthttp.MethodGet, "/stable")
+				router.HandleFastHTTP(ctx)
+				if string(ctx.Response.Body()) != "[m]/stable" {
+					t.Errorf("expected [m]/stable, got %s", ctx.Response.Body())
+				}
+
+				path := fmt.Sprintf("/flags/%d", (i+j)%5)
+				ctx = buildFastHTTPRequestContext(fasthttp.MethodGet, path)
+				router.HandleFastHTTP(ctx)
+				if code := ctx.Response.StatusCode(); code != fasthttp.StatusOK && code != fasthttp.StatusNotFound {
+					t.Errorf("%s: unexpected status %d", path, code)
+				}
+
+				ctx = buildFastHTTPRequestContext(fasthttp.MethodGet, "/teapot")
+				router.HandleFastHTTP(ctx)
+				if code := ctx.Response.StatusCode(); code != fasthttp.StatusInternalServerError && code != fasthttp.StatusTeapot {
+					t.Errorf("/teapot: unexpected status %d", code)
+				}
+
+				_, _ = router.URL("flag")
+				_ = router.Routes()
+			}
+		}(i)
+	}
+	wg.Wait()
+	<-done
+
+	if routes := router.Routes(); len(routes) != 102 || routes[0].Pattern != "/stable" {
+		t.Errorf("expected stable route and host routes to be left, got %+v", routes)
+	}
+}
+
+func TestFastHTTPUpdate(t *testing.T) {
+	t.Parallel()
+
+	handler := func(ctx *fasthttp.RequestCtx) {
+		_, _ = fmt.Fprint(ctx, string(ctx.Path()))
+	}
+
+	router := NewFastHTTPRouterWithOptions(WithConcurrentUpdates(true))
+	router.USE(fasthttp.MethodGet, "/", mockFastHTTPMiddleware("[a]"))
+	router.GET("/x", handler)
+
+	tests := []struct {
+		path   string
+		before int
+		after  string
+	}{
+		{"/x", fasthttp.StatusOK, "[a][b]/x"},
+		{"/y", fasthttp.StatusNotFound, "[a][b]/y"},
+		{"/api/z", fasthttp.StatusNotFound, "[a][b]/api/z"},
+	}
+
+	router.Update(func(r FastHTTPRouter) {
+		r.USE(fasthttp.MethodGet, "/", mockFastHTTPMiddleware("[b]"))
+		r.GET("/y", handler)
+		r.Group("/api", func(g FastHTTPRouteGroup) {
+			g.Update(func(g FastHTTPRouteGroup) {
+				g.GET("/z", handler)
+			})
+		})
+
+		for _, tt := range tests {
+			ctx := buildFastHTTPRequestContext(fasthttp.MethodGet, tt.path)
+			router.HandleFastHTTP(ctx)
+
+			if code := ctx.Response.StatusCode(); code != tt.before {
+				t.Errorf("%s: expected routes from before update status %d, got %d", tt.path, tt.before, code)
+			}
+			if tt.before == fasthttp.StatusOK && string(ctx.Response.Body()) != "[a]/x" {
+				t.Errorf("%s: expected routes from before update [a]/x, got %s", tt.path, ctx.Response.Body())
+			}
+		}
+	})
+
+	for _, tt := range tests {
+		ctx := buildFastHTTPRequestContext(fasthttp.MethodGet, tt.path)
+		router.HandleFastHTTP(ctx)
+
+		if string(ctx.Response.Body()) != tt.after {
+			t.Errorf("%s: expected updated routes %q, got %q", tt.path, tt.after, ctx.Response.Body())
+		}
+	}
+}
+
+func TestFastHTTPConcurrentUpdatesKeepPublishedHandlers(t *testing.T) {
+	t.Parallel()
+
+	handler := func(ctx *fasthttp.RequestCtx) {
+		_, _ = fmt.Fprint(ctx, string(ctx.Path()))
+	}
+
+	router := NewFastHTTPRouterWithOptions(WithConcurrentUpdates(true)).(*fastHTTPRouter)
+	router.USE(fasthttp.MethodGet, "/", mockFastHTTPMiddleware("[a]"))
+	router.GET("/x", handler)
+
+	table := router.live.load(&router.tree, &router.hosts, router.handlers)
+	tree, handlers := table.tree, table.handlers
+	router.USE(fasthttp.MethodGet, "/", mockFastHTTPMiddleware("[b]"))
+
+	// requests in flight keep being served with handlers of the trees they loaded
+	rt, _ := tree.Find(fasthttp.MethodGet).Tree().MatchRoute("x")
+	ctx := buildFastHTTPRequestContext(fasthttp.MethodGet, "/x")
+	handlers[rt.(*route)].(fasthttp.RequestHandler)(ctx)
+
+	if string(ctx.Response.Body()) != "[a]/x" {
+		t.Errorf("expected handler of published trees to be kept, got %s", ctx.Response.Body())
+	}
+}
+
+func TestFastHTTPReplace(t *testing.T) {
+	t.Parallel()
+
+	body := func(s string) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			_, _ = fmt.Fprint(ctx, s)
+		}
+	}
+
+	router := NewFastHTTPRouter()
+	router.USE(fasthttp.MethodGet, "/users", mockFastHTTPMiddleware("[m]"))
+	router.GET("/users/{id}", body("a"), "user")
+	router.GET("/posts", body("a"))
+	router.Mount("/files", body("files"))
+	router.Compile()
+
+	router.Replace(fasthttp.MethodGet, "/users/{id}", body("b"))
+	router.Replace(fasthttp.MethodGet, "/new", body("new"))
+	router.Replace(fasthttp.MethodGet, "posts", body("c"))
+
+	tests := []struct {
+		path string
+		body string
+	}{
+		{"/users/1", "[m]b"},
+		{"/new", "new"},
+		{"/posts", "c"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			ctx := buildFastHTTPRequestContext(fasthttp.MethodGet, tt.path)
+			router.HandleFastHTTP(ctx)
+
+			if string(ctx.Response.Body()) != tt.body {
+				t.Errorf("expected %s, got %s", tt.body, ctx.Response.Body())
+			}
+		})
+	}
+
+	if url, err := router.URL("user", "id", "1"); err != nil || url != "/users/1" {
+		t.Errorf("expected replaced route to keep its name, got %q %v", url, err)
+	}
+
+	for _, pattern := range []string{"/files", "/users/{name}"} {
+		t.Run(pattern, func(t *testing.T) {
+			defer func() {
+				if rcv := recover(); rcv == nil {
+					t.Error("expected conflict panic")
+				}
+			}()
+
+			router.Replace(fasthttp.MethodGet, pattern, body("c"))
+		})
+	}
+}
+
+func TestFastHTTPRemoveCompiled(t *testing.T) {
+	t.Parallel()
+
+	handler := func(ctx *fasthttp.RequestCtx) {
+		_, _ = fmt.Fprint(ctx, string(ctx.Path()))
+	}
+
+	router := NewFastHTTPRouter()
+	router.USE(fasthttp.MethodGet, "/", mockFastHTTPMiddleware("[g]"))
+	router.USE(fasthttp.MethodGet, "/admin", mockFastHTTPMiddleware("[a]"))
+	router.GET("/admin/x", handler)
+	router.GET("/x/y/z", handler)
+	router.Compile()
+	router.GET("/x/y/w", handler)
+
+	router.Remove(fasthttp.MethodGet, "/admin/x")
+	router.Remove(fasthttp.MethodGet, "/x/y/z")
+	router.GET("/admin/y", handler)
+
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/admin/x", fasthttp.StatusNotFound, ""},
+		{"/admin/y", fasthttp.StatusOK, "[g]/admin/y"},
+		{"/x/y/z", fasthttp.StatusNotFound, ""},
+		{"/x/y/w", fasthttp.StatusOK, "[g]/x/y/w"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			ctx := buildFastHTTPRequestContext(fasthttp.MethodGet, tt.path)
+			router.HandleFastHTTP(ctx)
+
+			if ctx.Response.StatusCode() != tt.code {
+				t.Errorf("expected status %d, got %d", tt.code, ctx.Response.StatusCode())
+			}
+			if tt.body != "" && string(ctx.Response.Body()) != tt.body {
+				t.Errorf("expected %s, got %s", tt.body, ctx.Response.Body())
+			}
+		})
+	}
+}
+
+func TestFastHTTPOptionalParams(t *testing.T) {
+	t.Parallel()
+
+	handler := func(ctx *fasthttp.RequestCtx) {
+		params, _ := ctx.UserValue("params").(context.Params)
+
+		year, err := params.Int("year")
+		if errors.Is(err, context.ErrParamNotFound) {
+			_, _ = fmt.Fprint(ctx, "all")
+			return
+		}
+
+		_, _ = fmt.Fprintf(ctx, "%d %t", year, params.Has("month"))
+	}
+
+	router := NewFastHTTPRouter()
+	router.GET("/reports/{year?:[0-9]+}/{month?}", handler, "reports")
+
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/reports", fasthttp.StatusOK, "all"},
+		{"/reports/2024", fasthttp.StatusOK, "2024 false"},
+		{"/reports/2024/05", fasthttp.StatusOK, "2024 true"},
+		{"/reports/latest", fasthttp.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			ctx := buildFastHTTPRequestContext(fasthttp.MethodGet, tt.path)
+			router.HandleFastHTTP(ctx)
+
+			if ctx.Response.StatusCode() != tt.code {
+				t.Errorf("expected status %d, got %d", tt.code, ctx.Response.StatusCode())
+			}
+			if tt.body != "" && string(ctx.Response.Body()) != tt.body {
+				t.Errorf("expected %s, got %s", tt.body, ctx.Response.Body())
+			}
+		})
+	}
+
+	if url, err := router.URL("reports", "year", "2024"); err != nil || url != "/reports/2024" {
+		t.Errorf("expected /reports/2024, got %q %v", url, err)
+	}
+
+	if err := router.TryHandle(fasthttp.MethodGet, "/reports", handler); err == nil {
+		t.Error("expected expanded path to conflict")
+	}
+
+	if !router.Remove(fasthttp.MethodGet, "/reports/{year?:[0-9]+}/{month?}") {
+		t.Fatal("expected route to be removed")
+	}
+	if routes := router.Routes(); len(routes) != 0 {
+		t.Errorf("expected routes of all expanded paths to be removed, got %+v", routes)
+	}
+}
+
+func TestFastHTTPTemplateParams(t *testing.T) {
+	t.Parallel()
+
+	handler := func(ctx *fasthttp.RequestCtx) {
+		params, _ := ctx.UserValue("params").(context.Params)
+		_, _ = fmt.Fprintf(ctx, "%s %s", params.Value("name"), params.Value("ext"))
+	}
+
+	router := NewFastHTTPRouter()
+	router.GET("/files/{name}.{ext:[a-z]+}", handler, "file")
+	router.GET("/files/{name}", func(ctx *fasthttp.RequestCtx) {
+		_, _ = fmt.Fprint(ctx, "plain")
+	})
+
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/files/archive.tar.gz", fasthttp.StatusOK, "archive.tar gz"},
+		{"/files/report.pdf", fasthttp.StatusOK, "report pdf"},
+		{"/files/report.123", fasthttp.StatusOK, "plain"},
+		{"/files/report", fasthttp.StatusOK, "plain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			ctx := buildFastHTTPRequestContext(fasthttp.MethodGet, tt.path)
+			router.HandleFastHTTP(ctx)
+
+			if ctx.Response.StatusCode() != tt.code {
+				t.Errorf("expected status %d, got %d", tt.code, ctx.Response.StatusCode())
+			}
+			if string(ctx.Response.Body()) != tt.body {
+				t.Errorf("expected %s, got %s", tt.body, ctx.Response.Body())
+			}
+		})
+	}
+
+	if url, err := router.URL("file", "name", "report", "ext", "pdf"); err != nil || url != "/files/report.pdf" {
+		t.Errorf("expected /files/report.pdf, got %q %v", url, err)
+	}
+
+	if !strings.Contains(router.PrettyPrint(), "{name}.{ext:[a-z]+}") {
+		t.Errorf("PrettyPrint() should contain template node: %s", router.PrettyPrint())
+	}
+}
+
+func TestFastHTTPGroupScope(t *testing.T) {
+	t.Parallel()
+
+	router := NewFastHTTPRouter().(*fastHTTPRouter)
+	router.TrailingSlash(TrailingSlashStrict)
+	router.GET("/x", (&mockHandler{}).HandleFastHTTP)
+
+	router.Group("/api", func(api FastHTTPRouteGroup) {
+		api.GET("/", func(ctx *fasthttp.RequestCtx) {
+			_, _ = fmt.Fprint(ctx, "api")
+		})
+		api.GET("/users", (&mockHandler{}).HandleFastHTTP)
+
+		api.Host("admin.example.com").GET("/stats", func(ctx *fasthttp.RequestCtx) {
+			_, _ = fmt.Fprint(ctx, "stats")
+		})
+
+		var patterns []string
+		for _, info := range api.Routes() {
+			patterns = append(patterns, info.Host+info.Pattern)
+		}
+		if !reflect.DeepEqual(patterns, []string{"/api", "/api/users", "admin.example.com/api/stats"}) {
+			t.Errorf("expected routes under the prefix only, got %v", patterns)
+		}
+	})
+
+	tests := []struct {
+		host string
+		path string
+		body string
+	}{
+		{"example.com", "/api", "api"},
+		{"admin.example.com", "/api/stats", "stats"},
+	}
+	for _, tt := range tests {
+		ctx := buildFastHTTPRequestContext(fasthttp.MethodGet, tt.path)
+		ctx.URI().SetHost(tt.host)
+
+		router.HandleFastHTTP(ctx)
+
+		if string(ctx.Response.Body()) != tt.body {
+			t.Errorf("%s%s: expected %s, got %s", tt.host, tt.path, tt.body, ctx.Response.Body())
+		}
+	}
+}
+
+func TestFastHTTPGroupMiddlewareScope(t *testing.T) {
+	t.Parallel()
+
+	handler := func(ctx *fasthttp.RequestCtx) {
+		_, _ = fmt.Fprint(ctx, string(ctx.Path()))
+	}
+
+	for _, compiled := range []bool{false, true} {
+		router := NewFastHTTPRouter()
+		router.Group("/api/v1", func(g FastHTTPRouteGroup) {
+			g.USE(fasthttp.MethodGet, "/", mockFastHTTPMiddleware("[v1]"))
+			g.GET("/users", handler)
+		})
+		router.GET("/api/v10/public", handler)
+
+		if compiled {
+			router.Compile()
+		}
+
+		tests := []struct {
+			path string
+			body string
+		}{
+			{"/api/v1/users", "[v1]/api/v1/users"},
+			{"/api/v10/public", "/api/v10/public"},
+		}
+		for _, tt := range tests {
+			ctx := buildFastHTTPRequestContext(fasthttp.MethodGet, tt.path)
+			router.HandleFastHTTP(ctx)
+
+			if string(ctx.Response.Body()) != tt.body {
+				t.Errorf("compiled %t %s: expected %s, got %s", compiled, tt.path, tt.body, ctx.Response.Body())
+			}
+		}
+	}
+}
+
+func TestFastHTTPCompiledMiddlewareAllocs(t *testing.T) {
+	noop := func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			next(ctx)
+		}
+	}
+
+	router := NewFastHTTPRouter(noop)
+	router.GET("/x/y/z", func(_ *fasthttp.RequestCtx) {})
+	router.USE(fasthttp.MethodGet, "/", noop)
+	router.USE(fasthttp.MethodGet, "/x", noop)
+	router.USE(fasthttp.MethodGet, "/x/y/z", noop)
+	router.Compile()
+
+	ctx := buildFastHTTPRequestContext(fasthttp.MethodGet, "/x/y/z")
+
+	if allocs := testing.AllocsPerRun(100, func() { router.HandleFastHTTP(ctx) }); allocs != 1 {
+		t.Errorf("Compiled middleware routing should only copy request path, got %v allocs", allocs)
+	}
+}
+
+func TestFastHTTPParamsOutliveRequestPath(t *testing.T) {
+	var params context.Params
+
+	router := NewFastHTTPRouter()
+	router.GET("/users/{id}", func(ctx *fasthttp.RequestCtx) {
+		params = ctx.UserValue("params").(context.Params)
+	})
+
+	ctx := buildFastHTTPRequestContext(fasthttp.MethodGet, "/users/42")
+	router.HandleFastHTTP(ctx)
+
+	// request is reused by fasthttp once served
+	ctx.URI().SetPath("/users/77")
+
+	if params.Value("id") != "42" {
+		t.Errorf("expected param value to be kept, got %s", params.Value("id"))
+	}
+}
@@ -0,0 +1,219 @@
+package gorouter
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+)
+
+// group registers routes of net/http Router under the path prefix
+type group struct {
+	*router
+	prefix string
+}
+
+func (g *group) POST(p string, f http.Handler, name ...string) {
+	g.Handle(http.MethodPost, p, f, name...)
+}
+
+func (g *group) GET(p string, f http.Handler, name ...string) {
+	g.Handle(http.MethodGet, p, f, name...)
+}
+
+func (g *group) PUT(p string, f http.Handler, name ...string) {
+	g.Handle(http.MethodPut, p, f, name...)
+}
+
+func (g *group) DELETE(p string, f http.Handler, name ...string) {
+	g.Handle(http.MethodDelete, p, f, name...)
+}
+
+func (g *group) PATCH(p string, f http.Handler, name ...string) {
+	g.Handle(http.MethodPatch, p, f, name...)
+}
+
+func (g *group) OPTIONS(p string, f http.Handler, name ...string) {
+	g.Handle(http.MethodOptions, p, f, name...)
+}
+
+func (g *group) HEAD(p string, f http.Handler, name ...string) {
+	g.Handle(http.MethodHead, p, f, name...)
+}
+
+func (g *group) CONNECT(p string, f http.Handler, name ...string) {
+	g.Handle(http.MethodConnect, p, f, name...)
+}
+
+func (g *group) TRACE(p string, f http.Handler, name ...string) {
+	g.Handle(http.MethodTrace, p, f, name...)
+}
+
+func (g *group) USE(method, path string, fs ...MiddlewareFunc) {
+	g.router.USE(method, joinPath(g.prefix, path), fs...)
+}
+
+func (g *group) USEANY(path string, fs ...MiddlewareFunc) {
+	g.router.USEANY(joinPath(g.prefix, path), fs...)
+}
+
+func (g *group) Handle(method, path string, h http.Handler, name ...string) {
+	g.router.Handle(method, joinPath(g.prefix, path), h, name...)
+}
+
+func (g *group) TryHandle(method, path string, h http.Handler, name ...string) error {
+	return g.router.TryHandle(method, joinPath(g.prefix, path), h, name...)
+}
+
+func (g *group) Replace(method, path string, h http.Handler) {
+	g.router.Replace(method, joinPath(g.prefix, path), h)
+}
+
+func (g *group) Remove(method, path string) bool {
+	return g.router.Remove(method, joinPath(g.prefix, path))
+}
+
+func (g *group) HandleE(method, path string, h func(http.ResponseWriter, *http.Request) error, name ...string) {
+	g.router.HandleE(method, joinPath(g.prefix, path), h, name...)
+}
+
+func (g *group) Mount(path string, h http.Handler) {
+	g.router.Mount(joinPath(g.prefix, path), h)
+}
+
+func (g *group) Update(fn func(g RouteGroup)) {
+	g.router.Update(func(Router) {
+		fn(g)
+	})
+}
+
+func (g *group) Group(prefix string, fn func(g RouteGroup)) {
+	g.router.Group(joinPath(g.prefix, prefix), fn)
+}
+
+func (g *group) Host(pattern string) RouteGroup {
+	return &group{router: g.router.Host(pattern).(*router), prefix: g.prefix}
+}
+
+func (g *group) Routes() []RouteInfo {
+	return groupRoutes(g.router.Routes(), g.prefix)
+}
+
+// fastHTTPGroup registers routes of FastHTTPRouter under the path prefix
+type fastHTTPGroup struct {
+	*fastHTTPRouter
+	prefix string
+}
+
+func (g *fastHTTPGroup) POST(p string, f fasthttp.RequestHandler, name ...string) {
+	g.Handle(fasthttp.MethodPost, p, f, name...)
+}
+
+func (g *fastHTTPGroup) GET(p string, f fasthttp.RequestHandler, name ...string) {
+	g.Handle(fasthttp.MethodGet, p, f, name...)
+}
+
+func (g *fastHTTPGroup) PUT(p string, f fasthttp.RequestHandler, name ...string) {
+	g.Handle(fasthttp.MethodPut, p, f, name...)
+}
+
+func (g *fastHTTPGroup) DELETE(p string, f fasthttp.RequestHandler, name ...string) {
+	g.Handle(fasthttp.MethodDelete, p, f, name...)
+}
+
+func (g *fastHTTPGroup) PATCH(p string, f fasthttp.RequestHandler, name ...string) {
+	g.Handle(fasthttp.MethodPatch, p, f, name...)
+}
+
+func (g *fastHTTPGroup) OPTIONS(p string, f fasthttp.RequestHandler, name ...string) {
+	g.Handle(fasthttp.MethodOptions, p, f, name...)
+}
+
+func (g *fastHTTPGroup) HEAD(p string, f fasthttp.RequestHandler, name ...string) {
+	g.Handle(fasthttp.MethodHead, p, f, name...)
+}
+
+func (g *fastHTTPGroup) CONNECT(p string, f fasthttp.RequestHandler, name ...string) {
+	g.Handle(fasthttp.MethodConnect, p, f, name...)
+}
+
+func (g *fastHTTPGroup) TRACE(p string, f fasthttp.RequestHandler, name ...string) {
+	g.Handle(fasthttp.MethodTrace, p, f, name...)
+}
+
+func (g *fastHTTPGroup) USE(method, path string, fs ...FastHTTPMiddlewareFunc) {
+	g.fastHTTPRouter.USE(method, joinPath(g.prefix, path), fs...)
+}
+
+func (g *fastHTTPGroup) USEANY(path string, fs ...FastHTTPMiddlewareFunc) {
+	g.fastHTTPRouter.USEANY(joinPath(g.prefix, path), fs...)
+}
+
+func (g *fastHTTPGroup) Handle(method, path string, h fasthttp.RequestHandler, name ...string) {
+	g.fastHTTPRouter.Handle(method, joinPath(g.prefix, path), h, name...)
+}
+
+func (g *fastHTTPGroup) TryHandle(method, path string, h fasthttp.RequestHandler, name ...string) error {
+	return g.fastHTTPRouter.TryHandle(method, joinPath(g.prefix, path), h, name...)
+}
+
+func (g *fastHTTPGroup) Replace(method, path string, h fasthttp.RequestHandler) {
+	g.fastHTTPRouter.Replace(method, joinPath(g.prefix, path), h)
+}
+
+func (g *fastHTTPGroup) Remove(method, path string) bool {
+	return g.fastHTTPRouter.Remove(method, joinPath(g.prefix, path))
+}
+
+func (g *fastHTTPGroup) HandleE(method, path string, h func(*fasthttp.RequestCtx) error, name ...string) {
+	g.fastHTTPRouter.HandleE(method, joinPath(g.prefix, path), h, name...)
+}
+
+func (g *fastHTTPGroup) Mount(path string, h fasthttp.RequestHandler) {
+	g.fastHTTPRouter.Mount(joinPath(g.prefix, path), h)
+}
+
+func (g *fastHTTPGroup) Update(fn func(g FastHTTPRouteGroup)) {
+	g.fastHTTPRouter.Update(func(FastHTTPRouter) {
+		fn(g)
+	})
+}
+
+func (g *fastHTTPGroup) Group(prefix string, fn func(g FastHTTPRouteGroup)) {
+	g.fastHTTPRouter.Group(joinPath(g.prefix, prefix), fn)
+}
+
+func (g *fastHTTPGroup) Host(pattern string) FastHTTPRouteGroup {
+	return &fastHTTPGroup{fastHTTPRouter: g.fastHTTPRouter.Host(pattern).(*fastHTTPRouter), prefix: g.prefix}
+}
+
+func (g *fastHTTPGroup) Routes() []RouteInfo {
+	return groupRoutes(g.fastHTTPRouter.Routes(), g.prefix)
+}
+
+// joinPath joins prefix with the path making sure there is single slash between them,
+// prefix root path is joined without trailing slash, e.g. /users instead of /users/
+func joinPath(prefix, path string) string {
+	prefix = strings.TrimRight(prefix, "/")
+	path = strings.TrimLeft(path, "/")
+
+	if path == "" && prefix != "" {
+		return prefix
+	}
+
+	return prefix + "/" + path
+}
+
+// groupRoutes lists routes of the router registered under the group prefix
+func groupRoutes(routes []RouteInfo, prefix string) []RouteInfo {
+	prefix = strings.TrimRight(joinPath("/", prefix), "/")
+
+	var scoped []RouteInfo
+	for _, info := range routes {
+		if info.Pattern == prefix || strings.HasPrefix(info.Pattern, prefix+"/") {
+			scoped = append(scoped, info)
+		}
+	}
+
+	return scoped
+}
@@ -0,0 +1,88 @@
+package gorouter
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/vardius/gorouter/v4/mux"
+)
+
+// hostPatternPath converts host pattern to mux.Tree path,
+// labels become path parts, dots inside wildcards are kept
+func hostPatternPath(pattern string) string {
+	var b strings.Builder
+	b.Grow(len(pattern))
+
+	depth := 0
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+		case c == '.' && depth == 0:
+			c = '/'
+		case depth == 0 && 'A' <= c && c <= 'Z':
+			c += 'a' - 'A'
+		}
+
+		b.WriteByte(c)
+	}
+
+	return b.String()
+}
+
+// hostPath converts request host to mux.Tree path, port and trailing dot are removed
+func hostPath(host string) string {
+	if i := strings.LastIndexByte(host, ':'); i >= 0 && i > strings.LastIndexByte(host, ']') {
+		host = host[:i]
+	}
+
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+
+	return strings.ReplaceAll(host, ".", "/")
+}
+
+// hostPatternPort reports if host pattern has a port,
+// colons inside wildcards and IPv6 literal brackets are skipped
+func hostPatternPort(pattern string) bool {
+	depth, brackets := 0, false
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+		case c == '[' && depth == 0:
+			brackets = true
+		case c == ']' && depth == 0:
+			brackets = false
+		case c == ':' && depth == 0 && !brackets:
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkHost checks if host pattern can be added to the hosts tree,
+// patterns with port are rejected as request port is ignored when matching hosts
+func checkHost(t mux.Tree, pattern string) error {
+	if hostPatternPort(pattern) {
+		return fmt.Errorf("host %s must not contain port, request port is ignored", pattern)
+	}
+
+	var conflict *mux.ConflictError
+	if err := t.CheckRoute(hostPatternPath(pattern)); !errors.As(err, &conflict) {
+		return err
+	}
+
+	existing := conflict.Existing
+	if r, ok := conflict.Route.(*route); ok {
+		existing = r.pattern.Pattern
+	}
+
+	return fmt.Errorf("host %s conflicts with %s: %s", pattern, existing, conflict.Reason)
+}
@@ -0,0 +1,4 @@
+/*
+Package format provides validators of textual formats shared by router packages
+*/
+package format
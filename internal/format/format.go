@@ -0,0 +1,28 @@
+package format
+
+// IsUUID checks if s is UUID formatted as xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx
+func IsUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		switch i {
+		case 8, 13, 18, 23:
+			if s[i] != '-' {
+				return false
+			}
+		default:
+			if !IsHexByte(s[i]) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// IsHexByte checks if c is hexadecimal digit of any case
+func IsHexByte(c byte) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
@@ -0,0 +1,37 @@
+package format
+
+import "testing"
+
+func TestIsUUID(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"123e4567-e89b-12d3-a456-426614174000", true},
+		{"123E4567-E89B-12D3-A456-426614174000", true},
+		{"123e4567e89b12d3a456426614174000", false},
+		{"123e4567-e89b-12d3-a456-42661417400g", false},
+		{"123e4567-e89b-12d3-a456_426614174000", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsUUID(tt.value); got != tt.want {
+			t.Errorf("IsUUID(%q) = %t, want %t", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestIsHexByte(t *testing.T) {
+	for _, c := range []byte("0123456789abcdefABCDEF") {
+		if !IsHexByte(c) {
+			t.Errorf("IsHexByte(%q) = false, want true", c)
+		}
+	}
+
+	for _, c := range []byte("gG-/ ") {
+		if IsHexByte(c) {
+			t.Errorf("IsHexByte(%q) = true, want false", c)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package gorouter
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/vardius/gorouter/v4/mux"
+)
+
+// routeTable is a snapshot of router trees served with concurrent updates enabled,
+// published trees, handlers and settings are never changed
+type routeTable struct {
+	tree     mux.Tree
+	hosts    mux.Tree
+	handlers composedHandlers
+	settings interface{}
+}
+
+// liveRoutes publishes route table snapshots of router with concurrent updates enabled,
+// otherwise router trees are served directly and changing them is not synchronized
+type liveRoutes struct {
+	concurrent bool
+	mu         sync.RWMutex // guards router trees, named routes and host routers
+	table      atomic.Pointer[routeTable]
+	published  bool        // router trees are shared with the published table, they have to be copied to be changed
+	batches    int         // batches in progress, changes are published once all of them are done
+	settings   interface{} // router settings read while serving, replaced with changed copy instead of being changed
+}
+
+// update runs fn with exclusive access to router trees, with concurrent updates enabled
+// fn changes copies of the trees published together with precomposed route handlers once it returns,
+// changes made during batch are published once it is done
+func (l *liveRoutes) update(tree, hosts *mux.Tree, fn func()) {
+	if !l.concurrent {
+		fn()
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.published {
+		*tree, *hosts = tree.Clone(), hosts.Clone()
+		l.published = false
+	}
+
+	fn()
+
+	if l.batches == 0 {
+		l.publish(*tree, *hosts)
+	}
+}
+
+// batch runs fn publishing all the changes it makes to router trees at once
+func (l *liveRoutes) batch(tree, hosts *mux.Tree, fn func()) {
+	if !l.concurrent {
+		fn()
+		return
+	}
+
+	l.mu.Lock()
+	l.batches++
+	l.mu.Unlock()
+
+	defer func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if l.batches--; l.batches == 0 && !l.published {
+			l.publish(*tree, *hosts)
+		}
+	}()
+
+	fn()
+}
+
+// publish stores trees to be served, they can not be changed anymore
+func (l *liveRoutes) publish(tree, hosts mux.Tree) {
+	// route handlers are always precomposed, middleware counter can not be read while serving
+	l.table.Store(&routeTable{tree: tree, hosts: hosts, handlers: computeHandlers(tree), settings: l.settings})
+	l.published = true
+}
+
+// compose precomposes route handlers of the router trees,
+// with concurrent updates enabled they are composed when the trees are published instead
+func (l *liveRoutes) compose(tree mux.Tree, handlers *composedHandlers) {
+	if !l.concurrent {
+		*handlers = computeHandlers(tree)
+	}
+}
+
+// read runs fn with shared access to router trees
+func (l *liveRoutes) read(fn func()) {
+	if l.concurrent {
+		l.mu.RLock()
+		defer l.mu.RUnlock()
+	}
+
+	fn()
+}
+
+// configure replaces router settings, with concurrent updates enabled they are published together with router trees
+func (l *liveRoutes) configure(tree, hosts *mux.Tree, fn func() interface{}) {
+	l.update(tree, hosts, func() {
+		l.settings = fn()
+	})
+}
+
+// load returns trees, precomposed route handlers and router settings the request should be served with
+func (l *liveRoutes) load(tree, hosts *mux.Tree, handlers composedHandlers) routeTable {
+	if !l.concurrent {
+		return routeTable{tree: *tree, hosts: *hosts, handlers: handlers, settings: l.settings}
+	}
+
+	if table := l.table.Load(); table != nil {
+		return *table
+	}
+
+	return routeTable{settings: l.loadSettings()}
+}
+
+// loadSettings returns router settings the request should be served with
+func (l *liveRoutes) loadSettings() interface{} {
+	if !l.concurrent {
+		return l.settings
+	}
+
+	if table := l.table.Load(); table != nil {
+		return table.settings
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.settings
+}
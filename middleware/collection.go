@@ -12,9 +12,13 @@ func NewCollection(ms ...Middleware) Collection {
 	return ms
 }
 
-// Merge merges another middleware
+// Merge merges another middleware into new Collection
+// neither of merged collections is modified
 func (c Collection) Merge(m Collection) Collection {
-	return append(c, m...)
+	merged := make(Collection, 0, len(c)+len(m))
+	merged = append(merged, c...)
+
+	return append(merged, m...)
 }
 
 // Compose returns middleware composed to single WrapperFunc
@@ -30,7 +34,7 @@ func (c Collection) Compose(h Handler) Handler {
 	return h
 }
 
-// Sort sorts collection by priority
+// Sort sorts collection in place by priority
 func (c Collection) Sort() Collection {
 	sort.SliceStable(c, func(i, j int) bool {
 		return c[i].Priority() < c[j].Priority()
@@ -116,6 +116,29 @@ func TestMerge(t *testing.T) {
 	}
 }
 
+func TestMergeDoesNotModifyCollections(t *testing.T) {
+	m1 := WithPriority(mockMiddleware("1"), 2)
+	m2 := WithPriority(mockMiddleware("2"), 1)
+	m3 := WithPriority(mockMiddleware("3"), 0)
+
+	c := make(Collection, 1, 4)
+	c[0] = m1
+
+	merged := c.Merge(NewCollection(m2))
+	other := c.Merge(NewCollection(m3))
+	merged.Sort()
+
+	if len(c) != 1 || c[0] != m1 {
+		t.Error("Merge modified source collection")
+	}
+	if merged[0] != m2 || merged[1] != m1 {
+		t.Error("Merge result is incorrect")
+	}
+	if other[0] != m1 || other[1] != m3 {
+		t.Error("Merge result shares backing array with another merge")
+	}
+}
+
 func TestCompose(t *testing.T) {
 	m := NewCollection(mockMiddleware("1"))
 	h := m.Compose(nil)
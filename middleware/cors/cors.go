@@ -0,0 +1,266 @@
+package cors
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	headerOrigin           = "Origin"
+	headerVary             = "Vary"
+	headerAllow            = "Allow"
+	headerRequestMethod    = "Access-Control-Request-Method"
+	headerRequestHeaders   = "Access-Control-Request-Headers"
+	headerAllowOrigin      = "Access-Control-Allow-Origin"
+	headerAllowMethods     = "Access-Control-Allow-Methods"
+	headerAllowHeaders     = "Access-Control-Allow-Headers"
+	headerAllowCredentials = "Access-Control-Allow-Credentials"
+	headerExposeHeaders    = "Access-Control-Expose-Headers"
+	headerMaxAge           = "Access-Control-Max-Age"
+)
+
+// Option configures Cors
+type Option func(*Cors)
+
+// WithOrigins sets allowed origins, "*" allows any origin, any origin is allowed by default
+func WithOrigins(origins ...string) Option {
+	return func(c *Cors) {
+		c.origins = origins
+	}
+}
+
+// WithHeaders sets request headers allowed in cross-origin requests,
+// Accept, Content-Type and X-Requested-With are allowed by default, "*" allows any header
+func WithHeaders(headers ...string) Option {
+	return func(c *Cors) {
+		c.headers = headers
+	}
+}
+
+// WithExposedHeaders sets response headers exposed to cross-origin requests
+func WithExposedHeaders(headers ...string) Option {
+	return func(c *Cors) {
+		c.exposedHeaders = headers
+	}
+}
+
+// WithCredentials allows cross-origin requests with credentials,
+// request origin is replied instead of "*" when enabled
+func WithCredentials(allow bool) Option {
+	return func(c *Cors) {
+		c.credentials = allow
+	}
+}
+
+// WithMaxAge sets how long preflight results can be cached, not sent by default
+func WithMaxAge(maxAge time.Duration) Option {
+	return func(c *Cors) {
+		c.maxAge = maxAge
+	}
+}
+
+// Cors handles cross-origin requests
+type Cors struct {
+	origins        []string
+	headers        []string
+	exposedHeaders []string
+	credentials    bool
+	maxAge         time.Duration
+}
+
+// New creates Cors configured with given options
+func New(opts ...Option) *Cors {
+	c := &Cors{
+		origins: []string{"*"},
+		headers: []string{"Accept", "Content-Type", "X-Requested-With"},
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Handler is net/http middleware adding CORS headers to responses of cross-origin requests,
+// Vary: Origin is added to every response unless any origin is allowed with "*"
+func (c *Cors) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isPreflight(r.Method, r.Header.Get(headerRequestMethod)) {
+			c.setHeaders(w.Header().Set, w.Header().Add, r.Header.Get(headerOrigin))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// FastHTTPHandler is fasthttp middleware adding CORS headers to responses of cross-origin requests
+func (c *Cors) FastHTTPHandler(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		if !isPreflight(string(ctx.Method()), string(ctx.Request.Header.Peek(headerRequestMethod))) {
+			c.setHeaders(ctx.Response.Header.Set, ctx.Response.Header.Add, string(ctx.Request.Header.Peek(headerOrigin)))
+		}
+
+		next(ctx)
+	}
+}
+
+// Preflight replies to net/http preflight requests, it has to be registered
+// as router automatic OPTIONS handler as it relies on the Allow header set by the router,
+// not allowed preflight requests are replied without CORS headers
+func (c *Cors) Preflight(w http.ResponseWriter, r *http.Request) {
+	if !isPreflight(r.Method, r.Header.Get(headerRequestMethod)) {
+		return
+	}
+
+	h := w.Header()
+	h.Add(headerVary, headerOrigin)
+	h.Add(headerVary, headerRequestMethod)
+	h.Add(headerVary, headerRequestHeaders)
+
+	if c.preflight(h.Set, r.Header.Get(headerOrigin), r.Header.Get(headerRequestMethod), r.Header.Get(headerRequestHeaders), h.Get(headerAllow)) {
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// FastHTTPPreflight replies to fasthttp preflight requests the same way Preflight does
+func (c *Cors) FastHTTPPreflight(ctx *fasthttp.RequestCtx) {
+	if !isPreflight(string(ctx.Method()), string(ctx.Request.Header.Peek(headerRequestMethod))) {
+		return
+	}
+
+	h := &ctx.Response.Header
+	h.Add(headerVary, headerOrigin)
+	h.Add(headerVary, headerRequestMethod)
+	h.Add(headerVary, headerRequestHeaders)
+
+	if c.preflight(
+		h.Set,
+		string(ctx.Request.Header.Peek(headerOrigin)),
+		string(ctx.Request.Header.Peek(headerRequestMethod)),
+		string(ctx.Request.Header.Peek(headerRequestHeaders)),
+		string(h.Peek(headerAllow)),
+	) {
+		ctx.SetStatusCode(fasthttp.StatusNoContent)
+	}
+}
+
+// preflight sets preflight response headers, reports if request is allowed
+func (c *Cors) preflight(set func(key, value string), origin, method, headers, allow string) bool {
+	if !c.allowsOrigin(origin) || !containsToken(allow, method) || !c.allowsHeaders(headers) {
+		return false
+	}
+
+	set(headerAllowOrigin, c.allowOrigin(origin))
+	set(headerAllowMethods, allow)
+
+	if headers != "" {
+		set(headerAllowHeaders, headers)
+	}
+	if c.credentials {
+		set(headerAllowCredentials, "true")
+	}
+	if c.maxAge > 0 {
+		set(headerMaxAge, strconv.Itoa(int(c.maxAge/time.Second)))
+	}
+
+	return true
+}
+
+// setHeaders sets response headers of not preflight request, caches have to know the response
+// depends on the origin even for requests without it, unless it is the same for any origin
+func (c *Cors) setHeaders(set, add func(key, value string), origin string) {
+	if c.credentials || !contains(c.origins, "*") {
+		add(headerVary, headerOrigin)
+	}
+
+	if !c.allowsOrigin(origin) {
+		return
+	}
+
+	set(headerAllowOrigin, c.allowOrigin(origin))
+
+	if c.credentials {
+		set(headerAllowCredentials, "true")
+	}
+	if len(c.exposedHeaders) > 0 {
+		set(headerExposeHeaders, strings.Join(c.exposedHeaders, ", "))
+	}
+}
+
+// allowOrigin returns Access-Control-Allow-Origin value, "*" can not be used with credentials
+func (c *Cors) allowOrigin(origin string) string {
+	if !c.credentials && contains(c.origins, "*") {
+		return "*"
+	}
+
+	return origin
+}
+
+func (c *Cors) allowsOrigin(origin string) bool {
+	if origin == "" {
+		return false
+	}
+
+	for _, o := range c.origins {
+		if o == "*" || strings.EqualFold(o, origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// allowsHeaders checks if all comma separated request headers are allowed
+func (c *Cors) allowsHeaders(headers string) bool {
+	if contains(c.headers, "*") {
+		return true
+	}
+
+	for _, header := range strings.Split(headers, ",") {
+		if header = strings.TrimSpace(header); header != "" && !containsFold(c.headers, header) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isPreflight(method, requestMethod string) bool {
+	return method == http.MethodOptions && requestMethod != ""
+}
+
+// containsToken checks if comma separated list contains given token
+func containsToken(list, token string) bool {
+	for _, t := range strings.Split(list, ",") {
+		if strings.TrimSpace(t) == token {
+			return true
+		}
+	}
+
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,217 @@
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/vardius/gorouter/v4"
+)
+
+type request struct {
+	method        string
+	origin        string
+	requestMethod string
+	headers       string
+}
+
+type response struct {
+	code             int
+	allowOrigin      string
+	allowMethods     string
+	allowHeaders     string
+	allowCredentials string
+	exposeHeaders    string
+	maxAge           string
+	vary             string
+}
+
+var corsTests = []struct {
+	name     string
+	opts     []Option
+	request  request
+	expected response
+}{
+	{
+		name:     "same origin request",
+		request:  request{method: http.MethodGet},
+		expected: response{code: http.StatusOK},
+	},
+	{
+		name:     "any origin",
+		request:  request{method: http.MethodGet, origin: "https://a.com"},
+		expected: response{code: http.StatusOK, allowOrigin: "*"},
+	},
+	{
+		name:     "allowed origin",
+		opts:     []Option{WithOrigins("https://a.com"), WithExposedHeaders("X-Total")},
+		request:  request{method: http.MethodGet, origin: "https://a.com"},
+		expected: response{code: http.StatusOK, allowOrigin: "https://a.com", exposeHeaders: "X-Total", vary: "Origin"},
+	},
+	{
+		name:     "not allowed origin",
+		opts:     []Option{WithOrigins("https://a.com")},
+		request:  request{method: http.MethodGet, origin: "https://b.com"},
+		expected: response{code: http.StatusOK, vary: "Origin"},
+	},
+	{
+		name:     "same origin request with allowed origins",
+		opts:     []Option{WithOrigins("https://a.com")},
+		request:  request{method: http.MethodGet},
+		expected: response{code: http.StatusOK, vary: "Origin"},
+	},
+	{
+		name:     "same origin request with credentials",
+		opts:     []Option{WithCredentials(true)},
+		request:  request{method: http.MethodGet},
+		expected: response{code: http.StatusOK, vary: "Origin"},
+	},
+	{
+		name:     "credentials",
+		opts:     []Option{WithCredentials(true)},
+		request:  request{method: http.MethodGet, origin: "https://a.com"},
+		expected: response{code: http.StatusOK, allowOrigin: "https://a.com", allowCredentials: "true", vary: "Origin"},
+	},
+	{
+		name:     "OPTIONS request",
+		request:  request{method: http.MethodOptions, origin: "https://a.com"},
+		expected: response{code: http.StatusOK, allowOrigin: "*"},
+	},
+	{
+		name:    "preflight",
+		opts:    []Option{WithOrigins("https://a.com"), WithCredentials(true), WithMaxAge(time.Hour)},
+		request: request{method: http.MethodOptions, origin: "https://a.com", requestMethod: http.MethodPost, headers: "content-type"},
+		expected: response{
+			code:             http.StatusNoContent,
+			allowOrigin:      "https://a.com",
+			allowMethods:     "GET, POST, HEAD, OPTIONS",
+			allowHeaders:     "content-type",
+			allowCredentials: "true",
+			maxAge:           "3600",
+			vary:             "Origin",
+		},
+	},
+	{
+		name:     "preflight with not allowed method",
+		request:  request{method: http.MethodOptions, origin: "https://a.com", requestMethod: http.MethodDelete},
+		expected: response{code: http.StatusOK, vary: "Origin"},
+	},
+	{
+		name:     "preflight with not allowed header",
+		request:  request{method: http.MethodOptions, origin: "https://a.com", requestMethod: http.MethodGet, headers: "X-Custom"},
+		expected: response{code: http.StatusOK, vary: "Origin"},
+	},
+	{
+		name:     "preflight with any header allowed",
+		opts:     []Option{WithHeaders("*")},
+		request:  request{method: http.MethodOptions, origin: "https://a.com", requestMethod: http.MethodGet, headers: "X-Custom"},
+		expected: response{code: http.StatusNoContent, allowOrigin: "*", allowMethods: "GET, POST, HEAD, OPTIONS", allowHeaders: "X-Custom", vary: "Origin"},
+	},
+	{
+		name:     "preflight with not allowed origin",
+		opts:     []Option{WithOrigins("https://a.com")},
+		request:  request{method: http.MethodOptions, origin: "https://b.com", requestMethod: http.MethodGet},
+		expected: response{code: http.StatusOK, vary: "Origin"},
+	},
+}
+
+func TestCors(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range corsTests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := New(tt.opts...)
+			router := gorouter.NewWithOptions(
+				gorouter.WithMiddleware(c.Handler),
+				gorouter.WithAutoOptionsHandler(http.HandlerFunc(c.Preflight)),
+			)
+			handler := http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {})
+			router.GET("/x", handler)
+			router.POST("/x", handler)
+
+			req := httptest.NewRequest(tt.request.method, "/x", nil)
+			if tt.request.origin != "" {
+				req.Header.Set("Origin", tt.request.origin)
+			}
+			if tt.request.requestMethod != "" {
+				req.Header.Set("Access-Control-Request-Method", tt.request.requestMethod)
+			}
+			if tt.request.headers != "" {
+				req.Header.Set("Access-Control-Request-Headers", tt.request.headers)
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			got := response{
+				code:             w.Code,
+				allowOrigin:      w.Header().Get("Access-Control-Allow-Origin"),
+				allowMethods:     w.Header().Get("Access-Control-Allow-Methods"),
+				allowHeaders:     w.Header().Get("Access-Control-Allow-Headers"),
+				allowCredentials: w.Header().Get("Access-Control-Allow-Credentials"),
+				exposeHeaders:    w.Header().Get("Access-Control-Expose-Headers"),
+				maxAge:           w.Header().Get("Access-Control-Max-Age"),
+				vary:             w.Header().Get("Vary"),
+			}
+			if got != tt.expected {
+				t.Errorf("expected %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestFastHTTPCors(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range corsTests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := New(tt.opts...)
+			router := gorouter.NewFastHTTPRouterWithOptions(
+				gorouter.WithFastHTTPMiddleware(c.FastHTTPHandler),
+				gorouter.WithFastHTTPAutoOptionsHandler(c.FastHTTPPreflight),
+			)
+			handler := func(_ *fasthttp.RequestCtx) {}
+			router.GET("/x", handler)
+			router.POST("/x", handler)
+
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Request.Header.SetMethod(tt.request.method)
+			ctx.URI().SetPath("/x")
+			if tt.request.origin != "" {
+				ctx.Request.Header.Set("Origin", tt.request.origin)
+			}
+			if tt.request.requestMethod != "" {
+				ctx.Request.Header.Set("Access-Control-Request-Method", tt.request.requestMethod)
+			}
+			if tt.request.headers != "" {
+				ctx.Request.Header.Set("Access-Control-Request-Headers", tt.request.headers)
+			}
+
+			router.HandleFastHTTP(ctx)
+
+			h := &ctx.Response.Header
+			got := response{
+				code:             ctx.Response.StatusCode(),
+				allowOrigin:      string(h.Peek("Access-Control-Allow-Origin")),
+				allowMethods:     string(h.Peek("Access-Control-Allow-Methods")),
+				allowHeaders:     string(h.Peek("Access-Control-Allow-Headers")),
+				allowCredentials: string(h.Peek("Access-Control-Allow-Credentials")),
+				exposeHeaders:    string(h.Peek("Access-Control-Expose-Headers")),
+				maxAge:           string(h.Peek("Access-Control-Max-Age")),
+				vary:             string(h.Peek("Vary")),
+			}
+			if got != tt.expected {
+				t.Errorf("expected %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,8 @@
+/*
+Package cors provide net/http and fasthttp Cross-Origin Resource Sharing handling
+
+Cors middleware adds CORS headers to responses of cross-origin requests and Cors preflight
+handler, registered as router automatic OPTIONS handler, replies to preflight requests
+allowing methods router computed for the Allow header.
+*/
+package cors
@@ -0,0 +1,9 @@
+/*
+Package recover provide net/http and fasthttp panic recovery middleware
+
+Recovered panics are logged with the stack trace and replied with the 500 Error code,
+unless response headers were already written. Registered as route middleware
+of the router created with gorouter.WithRoutePattern option,
+matched route pattern is logged as well.
+*/
+package recover
@@ -0,0 +1,190 @@
+package recover
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/vardius/gorouter/v4"
+	"github.com/vardius/gorouter/v4/context"
+)
+
+// Logger logs recovered panics
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// Option configures recovery middleware
+type Option func(*config)
+
+type config struct {
+	logger          Logger
+	handler         func(http.ResponseWriter, *http.Request, interface{})
+	fastHTTPHandler func(*fasthttp.RequestCtx, interface{})
+}
+
+func newConfig(opts ...Option) *config {
+	c := &config{
+		logger: log.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// WithLogger sets logger of recovered panics, standard logger is used by default
+func WithLogger(l Logger) Option {
+	return func(c *config) {
+		c.logger = l
+	}
+}
+
+// WithHandler sets net/http handler replying to the request when panic is recovered
+// instead of the 500 Error code, it is not called when response headers were already written
+func WithHandler(fn func(w http.ResponseWriter, r *http.Request, rcv interface{})) Option {
+	return func(c *config) {
+		c.handler = fn
+	}
+}
+
+// WithFastHTTPHandler sets fasthttp handler replying to the request when panic is recovered
+// instead of the 500 Error code, it is not called when connection was hijacked
+func WithFastHTTPHandler(fn func(ctx *fasthttp.RequestCtx, rcv interface{})) Option {
+	return func(c *config) {
+		c.fastHTTPHandler = fn
+	}
+}
+
+// New creates net/http panic recovery middleware,
+// when response headers were already written the response is aborted with http.ErrAbortHandler,
+// matched route pattern is logged only for route middleware, global middleware runs before the route is matched
+func New(opts ...Option) gorouter.MiddlewareFunc {
+	c := newConfig(opts...)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw := &responseWriter{ResponseWriter: w}
+
+			defer func() {
+				rcv := recover()
+				if rcv == nil {
+					return
+				}
+				if rcv == http.ErrAbortHandler {
+					panic(rcv)
+				}
+
+				route, _ := context.RoutePattern(r.Context())
+				c.logger.Printf("%s\n%s", message(r.Method, r.URL.Path, route, rcv), debug.Stack())
+
+				if rw.wroteHeader {
+					panic(http.ErrAbortHandler)
+				}
+
+				if c.handler != nil {
+					c.handler(w, r, rcv)
+				} else {
+					http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				}
+			}()
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// NewFastHTTP creates fasthttp panic recovery middleware,
+// matched route pattern is stored in the request ctx so it is logged by global middleware too
+// when panic happens after the route is matched
+func NewFastHTTP(opts ...Option) gorouter.FastHTTPMiddlewareFunc {
+	c := newConfig(opts...)
+
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			defer func() {
+				rcv := recover()
+				if rcv == nil {
+					return
+				}
+
+				route, _ := gorouter.FastHTTPRoutePattern(ctx)
+				c.logger.Printf("%s\n%s", message(string(ctx.Method()), string(ctx.Path()), route, rcv), debug.Stack())
+
+				if ctx.Hijacked() {
+					return
+				}
+
+				if c.fastHTTPHandler != nil {
+					c.fastHTTPHandler(ctx, rcv)
+				} else {
+					ctx.Error(fasthttp.StatusMessage(fasthttp.StatusInternalServerError), fasthttp.StatusInternalServerError)
+				}
+			}()
+
+			next(ctx)
+		}
+	}
+}
+
+func message(method, path string, route context.Route, rcv interface{}) string {
+	if route.Pattern == "" {
+		return fmt.Sprintf("panic recovered: %s %s: %v", method, path, rcv)
+	}
+
+	return fmt.Sprintf("panic recovered: %s %s (%s): %v", method, route.Pattern, path, rcv)
+}
+
+// responseWriter tracks if response headers were written
+type responseWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	// informational responses are followed by the final one
+	if code >= http.StatusOK || code == http.StatusSwitchingProtocols {
+		w.wroteHeader = true
+	}
+
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher if the original http.ResponseWriter does
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.wroteHeader = true
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker if the original http.ResponseWriter does
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("recover: http.Hijacker is not supported")
+	}
+
+	w.wroteHeader = true
+
+	return h.Hijack()
+}
+
+// Unwrap returns the original http.ResponseWriter, used by http.ResponseController
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,261 @@
+package recover
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/vardius/gorouter/v4"
+)
+
+type mockLogger struct {
+	logs []string
+}
+
+func (l *mockLogger) Printf(format string, v ...interface{}) {
+	l.logs = append(l.logs, fmt.Sprintf(format, v...))
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		handler http.HandlerFunc
+		code    int
+		body    string
+	}{
+		{
+			name:    "no panic",
+			handler: func(w http.ResponseWriter, _ *http.Request) { _, _ = w.Write([]byte("ok")) },
+			code:    http.StatusOK,
+			body:    "ok",
+		},
+		{
+			name:    "panic",
+			handler: func(_ http.ResponseWriter, _ *http.Request) { panic("boom") },
+			code:    http.StatusInternalServerError,
+			body:    "Internal Server Error\n",
+		},
+		{
+			name: "custom handler",
+			opts: []Option{WithHandler(func(w http.ResponseWriter, _ *http.Request, rcv interface{}) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				_, _ = fmt.Fprint(w, rcv)
+			})},
+			handler: func(_ http.ResponseWriter, _ *http.Request) { panic("boom") },
+			code:    http.StatusServiceUnavailable,
+			body:    "boom",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			logger := &mockLogger{}
+			router := gorouter.NewWithOptions(gorouter.WithRoutePattern(true))
+			router.USEANY("/", New(append(tt.opts, WithLogger(logger))...))
+			router.GET("/users/{id}", tt.handler)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+			if w.Code != tt.code {
+				t.Errorf("expected code %d, got %d", tt.code, w.Code)
+			}
+			if w.Body.String() != tt.body {
+				t.Errorf("expected body %q, got %q", tt.body, w.Body.String())
+			}
+
+			if tt.code == http.StatusOK {
+				if len(logger.logs) != 0 {
+					t.Errorf("expected no logs, got %v", logger.logs)
+				}
+				return
+			}
+			if len(logger.logs) != 1 {
+				t.Fatalf("expected one log, got %v", logger.logs)
+			}
+			if log := logger.logs[0]; !strings.HasPrefix(log, "panic recovered: GET /users/{id} (/users/1): boom\n") || !strings.Contains(log, "goroutine") {
+				t.Errorf("expected log with route pattern and stack trace, got %q", log)
+			}
+		})
+	}
+}
+
+func TestNewWrittenHeaders(t *testing.T) {
+	t.Parallel()
+
+	handlerCalled := false
+	logger := &mockLogger{}
+	h := New(WithLogger(logger), WithHandler(func(_ http.ResponseWriter, _ *http.Request, _ interface{}) {
+		handlerCalled = true
+	}))(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		panic("boom")
+	}))
+
+	w := httptest.NewRecorder()
+
+	defer func() {
+		if rcv := recover(); rcv != http.ErrAbortHandler {
+			t.Errorf("expected response to be aborted, got %v", rcv)
+		}
+		if handlerCalled {
+			t.Error("handler should not be called when headers were written")
+		}
+		if w.Code != http.StatusAccepted {
+			t.Errorf("expected code %d, got %d", http.StatusAccepted, w.Code)
+		}
+		if len(logger.logs) != 1 || !strings.HasPrefix(logger.logs[0], "panic recovered: GET /x: boom\n") {
+			t.Errorf("expected panic to be logged, got %v", logger.logs)
+		}
+	}()
+
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/x", nil))
+}
+
+func TestNewAbortHandler(t *testing.T) {
+	t.Parallel()
+
+	logger := &mockLogger{}
+	h := New(WithLogger(logger))(http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	defer func() {
+		if rcv := recover(); rcv != http.ErrAbortHandler {
+			t.Errorf("expected http.ErrAbortHandler, got %v", rcv)
+		}
+		if len(logger.logs) != 0 {
+			t.Errorf("expected no logs, got %v", logger.logs)
+		}
+	}()
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/x", nil))
+}
+
+func TestNewFastHTTP(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		handler fasthttp.RequestHandler
+		code    int
+		body    string
+	}{
+		{
+			name:    "no panic",
+			handler: func(ctx *fasthttp.RequestCtx) { ctx.SetBodyString("ok") },
+			code:    fasthttp.StatusOK,
+			body:    "ok",
+		},
+		{
+			name:    "panic",
+			handler: func(_ *fasthttp.RequestCtx) { panic("boom") },
+			code:    fasthttp.StatusInternalServerError,
+			body:    "Internal Server Error",
+		},
+		{
+			name: "custom handler",
+			opts: []Option{WithFastHTTPHandler(func(ctx *fasthttp.RequestCtx, rcv interface{}) {
+				ctx.SetStatusCode(fasthttp.StatusServiceUnavailable)
+				ctx.SetBodyString(fmt.Sprint(rcv))
+			})},
+			handler: func(_ *fasthttp.RequestCtx) { panic("boom") },
+			code:    fasthttp.StatusServiceUnavailable,
+			body:    "boom",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			logger := &mockLogger{}
+			router := gorouter.NewFastHTTPRouterWithOptions(gorouter.WithRoutePattern(true))
+			router.USEANY("/", NewFastHTTP(append(tt.opts, WithLogger(logger))...))
+			router.GET("/users/{id}", tt.handler)
+
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Request.Header.SetMethod(fasthttp.MethodGet)
+			ctx.URI().SetPath("/users/1")
+
+			router.HandleFastHTTP(ctx)
+
+			if ctx.Response.StatusCode() != tt.code {
+				t.Errorf("expected code %d, got %d", tt.code, ctx.Response.StatusCode())
+			}
+			if string(ctx.Response.Body()) != tt.body {
+				t.Errorf("expected body %q, got %q", tt.body, ctx.Response.Body())
+			}
+
+			if tt.code == fasthttp.StatusOK {
+				if len(logger.logs) != 0 {
+					t.Errorf("expected no logs, got %v", logger.logs)
+				}
+				return
+			}
+			if len(logger.logs) != 1 {
+				t.Fatalf("expected one log, got %v", logger.logs)
+			}
+			if log := logger.logs[0]; !strings.HasPrefix(log, "panic recovered: GET /users/{id} (/users/1): boom\n") || !strings.Contains(log, "goroutine") {
+				t.Errorf("expected log with route pattern and stack trace, got %q", log)
+			}
+		})
+	}
+}
+
+func TestNewGlobal(t *testing.T) {
+	t.Parallel()
+
+	logger := &mockLogger{}
+	router := gorouter.NewWithOptions(
+		gorouter.WithRoutePattern(true),
+		gorouter.WithMiddleware(New(WithLogger(logger))),
+	)
+	router.GET("/users/{id}", http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) { panic("boom") }))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected code %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+	if len(logger.logs) != 1 || !strings.HasPrefix(logger.logs[0], "panic recovered: GET /users/1: boom\n") {
+		t.Errorf("expected log without route pattern, got %v", logger.logs)
+	}
+}
+
+func TestNewFastHTTPGlobal(t *testing.T) {
+	t.Parallel()
+
+	logger := &mockLogger{}
+	router := gorouter.NewFastHTTPRouterWithOptions(
+		gorouter.WithRoutePattern(true),
+		gorouter.WithFastHTTPMiddleware(NewFastHTTP(WithLogger(logger))),
+	)
+	router.GET("/users/{id}", func(_ *fasthttp.RequestCtx) { panic("boom") })
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(fasthttp.MethodGet)
+	ctx.URI().SetPath("/users/1")
+
+	router.HandleFastHTTP(ctx)
+
+	if ctx.Response.StatusCode() != fasthttp.StatusInternalServerError {
+		t.Errorf("expected code %d, got %d", fasthttp.StatusInternalServerError, ctx.Response.StatusCode())
+	}
+	if len(logger.logs) != 1 || !strings.HasPrefix(logger.logs[0], "panic recovered: GET /users/{id} (/users/1): boom\n") {
+		t.Errorf("expected log with route pattern, got %v", logger.logs)
+	}
+}
@@ -30,6 +30,18 @@ func (mh *mockHandler) HandleFastHTTP(_ *fasthttp.RequestCtx) {
 	mh.served = true
 }
 
+type mockHTTPError struct {
+	code int
+}
+
+func (e mockHTTPError) Error() string {
+	return fmt.Sprintf("mock error %d", e.code)
+}
+
+func (e mockHTTPError) StatusCode() int {
+	return e.code
+}
+
 type mockFileSystem struct {
 	opened bool
 }
@@ -0,0 +1,229 @@
+package mux
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	pathutils "github.com/vardius/gorouter/v4/path"
+)
+
+// ConflictError is returned when path can not be added to the Tree
+// because it is ambiguous with or shadowed by already existing Node
+type ConflictError struct {
+	// Path being added
+	Path string
+	// Existing is path of the conflicting Node, e.g. GET/users/{id}
+	Existing string
+	// Route of the conflicting Node or the first Route within its Tree, nil if there is none
+	Route Route
+	// Reason describes the conflict
+	Reason string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s conflicts with %s: %s", e.Path, e.Existing, e.Reason)
+}
+
+// CheckRoute checks if Route can be added to the Tree under given path
+// without conflicting with already existing Nodes, returns *ConflictError if not
+// or error if Nodes can not be created from the path
+func (t Tree) CheckRoute(path string) error {
+	return t.checkConflict(path, false)
+}
+
+// CheckSubrouter checks if Subrouter Route can be added to the Tree under given path
+// without conflicting with already existing Nodes, returns *ConflictError if not
+// or error if Nodes can not be created from the path
+func (t Tree) CheckSubrouter(path string) error {
+	return t.checkConflict(path, true)
+}
+
+func (t Tree) checkConflict(path string, subrouter bool) error {
+	path = pathutils.TrimSlash(path)
+	if err := checkPattern(path); err != nil {
+		return err
+	}
+
+	for _, p := range pathutils.ExpandOptional(path) {
+		p = pathutils.TrimSlash(p)
+		if p == "" {
+			continue
+		}
+
+		if conflict := t.conflict(p, "", subrouter); conflict != nil {
+			conflict.Path = p
+			return conflict
+		}
+	}
+
+	return nil
+}
+
+// checkPattern checks if Nodes can be created from every part of the path
+func checkPattern(path string) error {
+	if err := pathutils.CheckOptional(path); err != nil {
+		return err
+	}
+
+	paths := pathutils.ExpandOptional(path)
+	parts := strings.Split(paths[len(paths)-1], "/")
+
+	for i, part := range parts {
+		switch {
+		case part == "":
+		case pathutils.IsTemplate(part):
+			if _, err := parseTemplate(part); err != nil {
+				return err
+			}
+		case pathutils.IsCatchAll(part):
+			if i < len(parts)-1 {
+				return fmt.Errorf("catch-all path part %s has to be the last one", part)
+			}
+		default:
+			if _, exp := pathutils.GetNameFromPart(part); exp != "" {
+				if _, ok := lookupConstraint(exp); ok {
+					continue
+				}
+				if _, err := compileRegexp(exp); err != nil {
+					return fmt.Errorf("invalid regexp of path part %s: %w", part, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// conflict walks the Tree along the path, prefix is path of the Tree parent Node
+func (t Tree) conflict(path, prefix string, subrouter bool) *ConflictError {
+	if node, subPath, ok := t.findCompiled(path); ok {
+		return nodeConflict(node, subPath, prefix, subrouter)
+	}
+
+	part, subPath := pathutils.GetPart(path)
+	candidate := NewNode(part, 0)
+	name := candidate.Name()
+
+	node := t.Find(name)
+	if node == nil {
+		for _, child := range t {
+			if route := firstRoute(child); route != nil && sameMatcher(candidate, child) {
+				return newConflictError(prefix, child, route, "ambiguous path segment")
+			}
+		}
+
+		return nil
+	}
+
+	if !samePattern(candidate, node) {
+		return newConflictError(prefix, node, firstRoute(node), "path segment already registered with different pattern")
+	}
+
+	return nodeConflict(node, subPath, prefix, subrouter)
+}
+
+// findCompiled finds static Node compiled from many path parts the path starts with,
+// returns the rest of the path to be matched within its Tree
+func (t Tree) findCompiled(path string) (Node, string, bool) {
+	for _, child := range t {
+		if child.Kind() != KindStatic || strings.IndexByte(child.Name(), '/') < 0 {
+			continue
+		}
+		if subPath, ok := consumePart(child, path); ok {
+			return child, subPath, true
+		}
+	}
+
+	return nil, "", false
+}
+
+// nodeConflict checks the rest of the path against Node matching the path part
+func nodeConflict(node Node, subPath, prefix string, subrouter bool) *ConflictError {
+	if subPath == "" {
+		if node.Route() != nil {
+			return newConflictError(prefix, node, node.Route(), "route already registered")
+		}
+		if route := firstRoute(node); route != nil && subrouter {
+			return newConflictError(prefix, node, route, "mounted handler shadows existing route")
+		}
+
+		return nil
+	}
+
+	if node.Kind() == KindSubrouter && node.Route() != nil {
+		return newConflictError(prefix, node, node.Route(), "route shadowed by mounted handler")
+	}
+
+	return node.Tree().conflict(subPath, joinPattern(prefix, nodePattern(node)), subrouter)
+}
+
+func newConflictError(prefix string, node Node, route Route, reason string) *ConflictError {
+	return &ConflictError{
+		Existing: joinPattern(prefix, nodePattern(node)),
+		Route:    route,
+		Reason:   reason,
+	}
+}
+
+func joinPattern(prefix, pattern string) string {
+	if prefix == "" {
+		return pattern
+	}
+
+	return prefix + "/" + pattern
+}
+
+// samePattern checks if equally named Nodes are created from the same pattern,
+// wildcard without regexp refers to the param Node of the same name
+func samePattern(candidate, node Node) bool {
+	if candidate.Kind() == KindWildcard {
+		switch unwrapSubrouter(node).Kind() {
+		case KindWildcard, KindRegexp, KindConstraint:
+			return true
+		}
+	}
+
+	return nodePattern(candidate) == nodePattern(node)
+}
+
+// sameMatcher checks if differently named Nodes match exactly the same path parts
+func sameMatcher(candidate, node Node) bool {
+	node = unwrapSubrouter(node)
+	if candidate.Kind() != node.Kind() {
+		return false
+	}
+
+	switch node.Kind() {
+	case KindWildcard, KindCatchAll:
+		return true
+	case KindRegexp, KindConstraint:
+		return candidate.Expression() == node.Expression()
+	case KindTemplate:
+		return candidate.(*templateNode).signature() == node.(*templateNode).signature()
+	default:
+		static := node.(*staticNode)
+		return static.ignoreCase && strings.EqualFold(candidate.Name(), static.name)
+	}
+}
+
+// firstRoute returns Route of the Node or the first Route within its Tree
+func firstRoute(node Node) Route {
+	if node.Route() != nil {
+		return node.Route()
+	}
+
+	var route Route
+	_ = Walk(node.Tree(), func(_ []Node, n Node) error {
+		if n.Route() == nil {
+			return nil
+		}
+
+		route = n.Route()
+		return errStopWalk
+	})
+
+	return route
+}
+
+var errStopWalk = errors.New("stop walk")
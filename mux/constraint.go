@@ -0,0 +1,172 @@
+package mux
+
+import (
+	"sync"
+	"time"
+
+	"github.com/vardius/gorouter/v4/context"
+	"github.com/vardius/gorouter/v4/internal/format"
+	"github.com/vardius/gorouter/v4/middleware"
+	pathutils "github.com/vardius/gorouter/v4/path"
+)
+
+var constraints = struct {
+	sync.RWMutex
+	matchers map[string]func(string) bool
+}{
+	matchers: map[string]func(string) bool{
+		"int":   isInt,
+		"uint":  isUint,
+		"uuid":  format.IsUUID,
+		"alpha": isAlpha,
+		"alnum": isAlnum,
+		"hex":   isHex,
+		"date":  isDate,
+	},
+}
+
+// RegisterConstraint registers path part matcher used by wildcards {name:constraint},
+// built-in constraints are: int, uint, uuid, alpha, alnum, hex and date (YYYY-MM-DD),
+// registering constraint under existing name replaces it for nodes created afterwards
+func RegisterConstraint(name string, fn func(string) bool) {
+	if name == "" {
+		panic("Constraint name can not be empty.")
+	}
+	if fn == nil {
+		panic("Constraint matcher can not be nil.")
+	}
+
+	constraints.Lock()
+	defer constraints.Unlock()
+
+	constraints.matchers[name] = fn
+}
+
+func lookupConstraint(name string) (func(string) bool, bool) {
+	constraints.RLock()
+	defer constraints.RUnlock()
+
+	fn, ok := constraints.matchers[name]
+
+	return fn, ok
+}
+
+func withConstraint(parent *staticNode, constraint string, match func(string) bool) *constraintNode {
+	return &constraintNode{
+		staticNode: parent,
+		constraint: constraint,
+		match:      match,
+	}
+}
+
+type constraintNode struct {
+	*staticNode
+
+	constraint string
+	match      func(string) bool
+}
+
+func (n *constraintNode) Kind() Kind {
+	return KindConstraint
+}
+
+func (n *constraintNode) Expression() string {
+	return n.constraint
+}
+
+func (n *constraintNode) MatchRoute(path string) (Route, context.Params) {
+	pathPart, subPath := pathutils.GetPart(path)
+	if !n.match(pathPart) {
+		return nil, nil
+	}
+
+	maxParamsSize := n.MaxParamsSize()
+
+	var route Route
+	var params context.Params
+
+	if subPath == "" || n.staticNode.skipSubPath {
+		route = n.route
+		params = make(context.Params, maxParamsSize)
+	} else {
+		route, params = n.children.MatchRoute(subPath)
+		if route == nil {
+			return nil, nil
+		}
+	}
+
+	params.Set(maxParamsSize-1, n.name, pathPart)
+
+	return route, params
+}
+
+func (n *constraintNode) MatchMiddleware(path string) middleware.Collection {
+	pathPart, subPath := pathutils.GetPart(path)
+	if !n.match(pathPart) {
+		return nil
+	}
+
+	if subPath == "" || n.staticNode.skipSubPath {
+		return n.middleware
+	}
+
+	if treeMiddleware := n.children.MatchMiddleware(subPath); treeMiddleware != nil {
+		return n.middleware.Merge(treeMiddleware)
+	}
+
+	return n.middleware
+}
+
+func isInt(s string) bool {
+	if len(s) > 1 && s[0] == '-' {
+		s = s[1:]
+	}
+
+	return isUint(s)
+}
+
+func isUint(s string) bool {
+	return matchBytes(s, func(c byte) bool { return '0' <= c && c <= '9' })
+}
+
+func isAlpha(s string) bool {
+	return matchBytes(s, isLetter)
+}
+
+func isAlnum(s string) bool {
+	return matchBytes(s, func(c byte) bool { return isLetter(c) || ('0' <= c && c <= '9') })
+}
+
+func isHex(s string) bool {
+	return matchBytes(s, format.IsHexByte)
+}
+
+// isDate checks if s is valid calendar date formatted as YYYY-MM-DD
+func isDate(s string) bool {
+	if len(s) != 10 || s[4] != '-' || s[7] != '-' {
+		return false
+	}
+
+	_, err := time.Parse("2006-01-02", s)
+
+	return err == nil
+}
+
+// matchBytes checks if s is not empty and all of its bytes match fn
+func matchBytes(s string, fn func(c byte) bool) bool {
+	if s == "" {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		if !fn(s[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isLetter(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
@@ -66,3 +66,28 @@ func Example() {
 	// 		{commentId:\d+}
 	// 		new
 }
+
+func ExampleWalk() {
+	tree := NewTree().
+		WithRoute("users/{id:int}", nil, 0).
+		WithRoute("users/{id:int}/posts/{slug:[a-z-]+}", nil, 0).
+		WithRoute("files/{path*}", nil, 0)
+
+	_ = Walk(tree, func(path []Node, n Node) error {
+		if exp := n.Expression(); exp != "" {
+			fmt.Printf("%d %s %s:%s\n", len(path), n.Kind(), n.Name(), exp)
+			return nil
+		}
+
+		fmt.Printf("%d %s %s\n", len(path), n.Kind(), n.Name())
+		return nil
+	})
+
+	// Output:
+	// 0 static users
+	// 1 constraint id:int
+	// 2 static posts
+	// 3 regexp slug:[a-z-]+
+	// 0 static files
+	// 1 catch-all path
+}
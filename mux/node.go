@@ -2,6 +2,7 @@ package mux
 
 import (
 	"regexp"
+	"strings"
 
 	"github.com/vardius/gorouter/v4/context"
 	"github.com/vardius/gorouter/v4/middleware"
@@ -24,9 +25,27 @@ func NewNode(pathPart string, maxParamsSize uint8) Node {
 
 	var node Node
 
-	if exp != "" {
+	if pathutils.IsTemplate(pathPart) {
+		parts, err := parseTemplate(pathPart)
+		if err != nil {
+			panic(err.Error())
+		}
+		node = withTemplate(static, parts)
+		static.maxParamsSize += node.(*templateNode).params
+	} else if exp != "" {
+		static.maxParamsSize++
+		if match, ok := lookupConstraint(exp); ok {
+			node = withConstraint(static, exp, match)
+		} else {
+			re, err := compileRegexp(exp)
+			if err != nil {
+				panic(err.Error())
+			}
+			node = withRegexp(static, exp, re)
+		}
+	} else if pathutils.IsCatchAll(pathPart) {
 		static.maxParamsSize++
-		node = withRegexp(static, regexp.MustCompile(exp))
+		node = withCatchAll(static)
 	} else if name != pathPart {
 		static.maxParamsSize++
 		node = withWildcard(static)
@@ -53,6 +72,10 @@ type RouteAware interface {
 	// will skip children match search and return current node directly
 	// this value is used when matching subrouter
 	SkipSubPath()
+	// IgnoreCase sets ignoreCase node property to true
+	// static node name will be matched case-insensitively,
+	// wildcard and regexp values are kept in their original case
+	IgnoreCase()
 }
 
 // MiddlewareAware represents middleware aware node
@@ -77,6 +100,11 @@ type Node interface {
 
 	// Name provides Node name
 	Name() string
+	// Kind provides Node type
+	Kind() Kind
+	// Expression provides regexp of KindRegexp Node or constraint name
+	// of KindConstraint Node, it is empty for other kinds
+	Expression() string
 	// Tree provides next level Node Tree
 	Tree() Tree
 	// WithChildren sets Node's Tree
@@ -92,13 +120,28 @@ type staticNode struct {
 
 	maxParamsSize uint8
 	skipSubPath   bool
+	ignoreCase    bool
+}
+
+// hasPrefix checks if path starts with node name
+func (n *staticNode) hasPrefix(path string) bool {
+	nameLength := len(n.name)
+	if len(path) < nameLength {
+		return false
+	}
+
+	if n.ignoreCase {
+		return strings.EqualFold(n.name, path[:nameLength])
+	}
+
+	return n.name == path[:nameLength]
 }
 
 func (n *staticNode) MatchRoute(path string) (Route, context.Params) {
 	nameLength := len(n.name)
 	pathLength := len(path)
 
-	if pathLength >= nameLength && n.name == path[:nameLength] {
+	if n.hasPrefix(path) {
 		if nameLength == pathLength || n.skipSubPath {
 			return n.route, make(context.Params, n.maxParamsSize)
 		}
@@ -114,10 +157,13 @@ func (n *staticNode) MatchMiddleware(path string) middleware.Collection {
 	nameLength := len(n.name)
 	pathLength := len(path)
 
-	if pathLength >= nameLength && n.name == path[:nameLength] {
+	if n.hasPrefix(path) {
 		if nameLength == pathLength || n.skipSubPath {
 			return n.middleware
 		}
+		if path[nameLength] != '/' { // name has to match the whole path part
+			return nil
+		}
 
 		if treeMiddleware := n.children.MatchMiddleware(path[nameLength+1:]); treeMiddleware != nil { // +1 because we wan to skip slash as well
 			return n.middleware.Merge(treeMiddleware)
@@ -133,6 +179,14 @@ func (n *staticNode) Name() string {
 	return n.name
 }
 
+func (n *staticNode) Kind() Kind {
+	return KindStatic
+}
+
+func (n *staticNode) Expression() string {
+	return ""
+}
+
 func (n *staticNode) Tree() Tree {
 	return n.children
 }
@@ -169,6 +223,10 @@ func (n *staticNode) SkipSubPath() {
 	n.skipSubPath = true
 }
 
+func (n *staticNode) IgnoreCase() {
+	n.ignoreCase = true
+}
+
 func withWildcard(parent *staticNode) *wildcardNode {
 	return &wildcardNode{staticNode: parent}
 }
@@ -177,6 +235,10 @@ type wildcardNode struct {
 	*staticNode
 }
 
+func (n *wildcardNode) Kind() Kind {
+	return KindWildcard
+}
+
 func (n *wildcardNode) MatchRoute(path string) (Route, context.Params) {
 	pathPart, subPath := pathutils.GetPart(path)
 	maxParamsSize := n.MaxParamsSize()
@@ -213,17 +275,40 @@ func (n *wildcardNode) MatchMiddleware(path string) middleware.Collection {
 	return n.middleware
 }
 
-func withRegexp(parent *staticNode, regexp *regexp.Regexp) *regexpNode {
+// partialMatchPrefix marks regexp allowed to match part of the path segment {name:~exp}
+const partialMatchPrefix = "~"
+
+func withRegexp(parent *staticNode, exp string, re *regexp.Regexp) *regexpNode {
 	return &regexpNode{
 		staticNode: parent,
-		regexp:     regexp,
+		regexp:     re,
+		exp:        exp,
+	}
+}
+
+// compileRegexp compiles expression anchored to match the whole path segment
+// unless it is prefixed with partialMatchPrefix
+func compileRegexp(exp string) (*regexp.Regexp, error) {
+	if strings.HasPrefix(exp, partialMatchPrefix) {
+		return regexp.Compile(exp[len(partialMatchPrefix):])
 	}
+
+	return regexp.Compile("^(?:" + exp + ")$")
 }
 
 type regexpNode struct {
 	*staticNode
 
 	regexp *regexp.Regexp
+	exp    string
+}
+
+func (n *regexpNode) Kind() Kind {
+	return KindRegexp
+}
+
+func (n *regexpNode) Expression() string {
+	return n.exp
 }
 
 func (n *regexpNode) MatchRoute(path string) (Route, context.Params) {
@@ -269,6 +354,45 @@ func (n *regexpNode) MatchMiddleware(path string) middleware.Collection {
 	return n.middleware
 }
 
+func withCatchAll(parent *staticNode) *catchAllNode {
+	return &catchAllNode{staticNode: parent}
+}
+
+type catchAllNode struct {
+	*staticNode
+}
+
+func (n *catchAllNode) Kind() Kind {
+	return KindCatchAll
+}
+
+func (n *catchAllNode) MatchRoute(path string) (Route, context.Params) {
+	if path == "" {
+		return nil, nil
+	}
+
+	maxParamsSize := n.MaxParamsSize()
+	params := make(context.Params, maxParamsSize)
+
+	params.Set(maxParamsSize-1, n.name, path)
+
+	return n.route, params
+}
+
+func (n *catchAllNode) MatchMiddleware(path string) middleware.Collection {
+	if path == "" {
+		return nil
+	}
+
+	return n.middleware
+}
+
+func (n *catchAllNode) WithChildren(t Tree) {
+	if len(t) > 0 {
+		panic("Catch-all node can not have children.")
+	}
+}
+
 func withSubrouter(parent Node) *subrouterNode {
 	parent.SkipSubPath()
 
@@ -279,6 +403,12 @@ type subrouterNode struct {
 	Node
 }
 
-func (n *subrouterNode) WithChildren(_ Tree) {
-	panic("Subrouter node can not have children.")
+func (n *subrouterNode) Kind() Kind {
+	return KindSubrouter
+}
+
+func (n *subrouterNode) WithChildren(t Tree) {
+	if len(t) > 0 {
+		panic("Subrouter node can not have children.")
+	}
 }
@@ -36,6 +36,144 @@ func TestNewNode(t *testing.T) {
 	case *regexpNode:
 		t.Fatalf("Expecting: *mux.wildcardNode. Wrong node type: %T\n", node)
 	}
+
+	node = NewNode("{path*}", 0)
+
+	if _, ok := node.(*catchAllNode); !ok {
+		t.Fatalf("Expecting: *mux.catchAllNode. Wrong node type: %T\n", node)
+	}
+
+	if node.Name() != "path" {
+		t.Fatalf("Expecting node name: path, got: %s\n", node.Name())
+	}
+
+	node = NewNode("{id:int}", 0)
+
+	if _, ok := node.(*constraintNode); !ok {
+		t.Fatalf("Expecting: *mux.constraintNode. Wrong node type: %T\n", node)
+	}
+}
+
+func TestConstraints(t *testing.T) {
+	tests := []struct {
+		constraint string
+		value      string
+		want       bool
+	}{
+		{"int", "42", true},
+		{"int", "-42", true},
+		{"int", "-", false},
+		{"int", "4a", false},
+		{"int", "", false},
+		{"uint", "42", true},
+		{"uint", "-42", false},
+		{"uuid", "123e4567-e89b-12d3-a456-426614174000", true},
+		{"uuid", "123e4567e89b12d3a456426614174000", false},
+		{"uuid", "123e4567-e89b-12d3-a456-42661417400g", false},
+		{"alpha", "abcXYZ", true},
+		{"alpha", "abc1", false},
+		{"alnum", "abc123", true},
+		{"alnum", "abc-123", false},
+		{"hex", "deadBEEF09", true},
+		{"hex", "0xff", false},
+		{"date", "2020-02-29", true},
+		{"date", "2021-02-29", false},
+		{"date", "2020-2-29", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.constraint+" "+tt.value, func(t *testing.T) {
+			match, ok := lookupConstraint(tt.constraint)
+			if !ok {
+				t.Fatalf("constraint %s not registered", tt.constraint)
+			}
+			if got := match(tt.value); got != tt.want {
+				t.Errorf("%s(%q) = %v, want %v", tt.constraint, tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterConstraint(t *testing.T) {
+	RegisterConstraint("even", func(s string) bool {
+		return isUint(s) && (s[len(s)-1]-'0')%2 == 0
+	})
+
+	route := newMockRoute("testroute")
+	tree := NewTree().WithRoute("{n:even}", route, 0)
+
+	if _, ok := tree[0].(*constraintNode); !ok {
+		t.Fatalf("Expecting: *mux.constraintNode. Wrong node type: %T\n", tree[0])
+	}
+
+	if r, params := tree.MatchRoute("42"); r != route || params.Value("n") != "42" {
+		t.Errorf("route did not match value %s", "42")
+	}
+	if r, _ := tree.MatchRoute("43"); r != nil {
+		t.Errorf("route should not match value %s", "43")
+	}
+
+	for _, tt := range []struct {
+		name string
+		fn   func(string) bool
+	}{
+		{"", isInt},
+		{"nil", nil},
+	} {
+		func() {
+			defer func() {
+				if rcv := recover(); rcv == nil {
+					t.Errorf("RegisterConstraint(%q) should panic", tt.name)
+				}
+			}()
+
+			RegisterConstraint(tt.name, tt.fn)
+		}()
+	}
+}
+
+func TestConstraintNodeMatchRoute(t *testing.T) {
+	intRoute := newMockRoute("testintroute")
+	regexpRoute := newMockRoute("testregexproute")
+	wildcardRoute := newMockRoute("testwildcardroute")
+
+	tree := NewTree()
+	tree = tree.WithRoute("users/{name}", wildcardRoute, 0)
+	tree = tree.WithRoute("users/{slug:[a-z]+}", regexpRoute, 0)
+	tree = tree.WithRoute("users/{id:int}", intRoute, 0)
+
+	users := tree[0].Tree()
+	if _, ok := users[0].(*constraintNode); !ok {
+		t.Fatalf("constraint node should be sorted first, got %T", users[0])
+	}
+
+	tests := []struct {
+		path          string
+		expectedRoute Route
+		expectedKey   string
+	}{
+		{"users/42", intRoute, "id"},
+		{"users/-42", intRoute, "id"},
+		{"users/john", regexpRoute, "slug"},
+		{"users/John42", wildcardRoute, "name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			route, params := tree.MatchRoute(tt.path)
+			if route != tt.expectedRoute {
+				t.Errorf("%s: expected route %v, got %v", tt.path, tt.expectedRoute, route)
+			}
+			if !params.Has(tt.expectedKey) {
+				t.Errorf("%s: expected param %s, got %v", tt.path, tt.expectedKey, params)
+			}
+		})
+	}
+
+	if url, err := tree.URL("users/{id:int}", context.Params{{Key: "id", Value: "42"}}); err != nil || url != "/users/42" {
+		t.Errorf("expected url %s, got %s (%v)", "/users/42", url, err)
+	}
+	if _, err := tree.URL("users/{id:int}", context.Params{{Key: "id", Value: "abc"}}); err == nil {
+		t.Error("expected error for value not matching constraint")
+	}
 }
 
 type mockroute struct {
@@ -214,6 +352,79 @@ func TestWildcardNodeMatchRoute(t *testing.T) {
 	}
 }
 
+func TestCatchAllNodeMatchRoute(t *testing.T) {
+	paramSize := 3
+	filesRoute := newMockRoute("testfilesroute")
+	pathRoute := newMockRoute("testpathroute")
+	params := make(context.Params, paramSize)
+
+	files := staticNode{name: "files", route: nil, maxParamsSize: uint8(paramSize)}
+	files.WithRoute(filesRoute)
+
+	path := NewNode("{path*}", files.MaxParamsSize())
+	path.WithRoute(pathRoute)
+
+	wildcard := NewNode("{name}", files.MaxParamsSize())
+	wildcard.WithChildren(wildcard.Tree().WithRoute("info", newMockRoute("testinforoute"), wildcard.MaxParamsSize()))
+
+	files.WithChildren(files.Tree().withNode(path).sort())
+	files.WithChildren(files.Tree().withNode(wildcard).sort())
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedRoute  Route
+		expectedParams context.Params
+	}{
+		{
+			name:           "Exact Match",
+			path:           "files",
+			expectedRoute:  filesRoute,
+			expectedParams: params,
+		},
+		{
+			name:           "Single Segment",
+			path:           "files/a.txt",
+			expectedRoute:  pathRoute,
+			expectedParams: append(params, context.Param{Key: "path", Value: "a.txt"}),
+		},
+		{
+			name:           "Multiple Segments",
+			path:           "files/a/b/c.txt",
+			expectedRoute:  pathRoute,
+			expectedParams: append(params, context.Param{Key: "path", Value: "a/b/c.txt"}),
+		},
+		{
+			name:           "Wildcard Before Catch-All",
+			path:           "files/a/info",
+			expectedRoute:  wildcard.Tree()[0].Route(),
+			expectedParams: append(params, context.Param{Key: "name", Value: "a"}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route, params := files.MatchRoute(tt.path)
+			if route != tt.expectedRoute {
+				t.Errorf("%s: expected route %v, got %v", tt.name, tt.expectedRoute, route)
+			}
+			if !reflect.DeepEqual(params, tt.expectedParams) {
+				t.Errorf("%s: expected params %v, got %v", tt.name, tt.expectedParams, params)
+			}
+		})
+	}
+}
+
+func TestCatchAllNodeWithChildren(t *testing.T) {
+	defer func() {
+		if rcv := recover(); rcv == nil {
+			t.Error("Catch-all node should panic when adding children")
+		}
+	}()
+
+	NewTree().WithRoute("files/{path*}/info", newMockRoute("testroute"), 0)
+}
+
 func TestRegexpdNodeMatchRoute(t *testing.T) {
 	paramSize := 3
 	productRoute := newMockRoute("testproductroute")
@@ -290,6 +501,13 @@ func TestRegexpdNodeMatchRoute(t *testing.T) {
 			expectedRoute:  nil,
 			expectedParams: nil,
 		},
+		{
+			name:           "No Partial Match",
+			node:           product,
+			path:           "product/xitem1/view",
+			expectedRoute:  nil,
+			expectedParams: nil,
+		},
 	}
 
 	for _, tt := range tests {
@@ -371,7 +589,7 @@ func TestStaticNodeMatchMiddleware(t *testing.T) {
 			name:           "StaticNode Match with only prefix",
 			node:           node3,
 			path:           "testxyz",
-			expectedResult: node3Middleware,
+			expectedResult: nil,
 		},
 		{
 			name:           "StaticNode No match",
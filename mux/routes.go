@@ -0,0 +1,126 @@
+package mux
+
+// RouteInfo describes Route registered within the Tree
+type RouteInfo struct {
+	Route Route
+	// Pattern of the Nodes leading to the Route, e.g. /users/{id:[0-9]+}
+	Pattern string
+	// Params names in order of appearance
+	Params []string
+	// Constraints maps params names to their regexps or constraints names
+	Constraints map[string]string
+	// Middleware is a number of middleware attached to the Nodes leading to the Route
+	Middleware int
+	// Subrouter is true for Routes of mounted handlers
+	Subrouter bool
+}
+
+// Routes lists Routes registered within the Tree in matching order,
+// Route registered with optional wildcards is listed once at the place of its shortest path
+// and described with the longest one, wildcards missing from the shortest path are marked optional
+func (t Tree) Routes() []RouteInfo {
+	var routes []RouteInfo
+
+	// parent Nodes are walked first, so the shortest path of the Route is found first
+	type listedRoute struct {
+		index    int
+		required int
+	}
+	listed := make(map[Route]listedRoute)
+
+	_ = Walk(t, func(path []Node, n Node) error {
+		if n.Route() == nil {
+			return nil
+		}
+
+		nodes := append(path, n)
+		if l, ok := listed[n.Route()]; ok {
+			routes[l.index] = newRouteInfo(nodes, l.required)
+			return nil
+		}
+
+		listed[n.Route()] = listedRoute{index: len(routes), required: len(nodes)}
+		routes = append(routes, newRouteInfo(nodes, len(nodes)))
+
+		return nil
+	})
+
+	return routes
+}
+
+// newRouteInfo describes Route of the last Node of given chain,
+// Nodes following the required ones are optional
+func newRouteInfo(nodes []Node, required int) RouteInfo {
+	last := nodes[len(nodes)-1]
+	info := RouteInfo{
+		Route:     last.Route(),
+		Subrouter: last.Kind() == KindSubrouter,
+	}
+
+	for i, node := range nodes {
+		if i < required {
+			info.Pattern += "/" + nodePattern(node)
+		} else {
+			info.Pattern += "/" + optionalPattern(node)
+		}
+		info.Middleware += len(node.Middleware())
+
+		switch n := unwrapSubrouter(node).(type) {
+		case *templateNode:
+			for _, part := range n.parts {
+				if !part.isParam() {
+					continue
+				}
+				if part.exp != "" {
+					if info.Constraints == nil {
+						info.Constraints = make(map[string]string)
+					}
+					info.Constraints[part.name] = part.exp
+				}
+
+				info.Params = append(info.Params, part.name)
+			}
+			continue
+		}
+
+		switch unwrapSubrouter(node).Kind() {
+		case KindStatic:
+			continue
+		case KindRegexp, KindConstraint:
+			if info.Constraints == nil {
+				info.Constraints = make(map[string]string)
+			}
+			info.Constraints[node.Name()] = node.Expression()
+		}
+
+		info.Params = append(info.Params, node.Name())
+	}
+
+	return info
+}
+
+// nodePattern returns path part pattern of the Node, e.g. {id:[0-9]+}
+func nodePattern(node Node) string {
+	switch unwrapSubrouter(node).Kind() {
+	case KindWildcard:
+		return "{" + node.Name() + "}"
+	case KindRegexp, KindConstraint:
+		return "{" + node.Name() + ":" + node.Expression() + "}"
+	case KindCatchAll:
+		return "{" + node.Name() + "*}"
+	default:
+		return node.Name()
+	}
+}
+
+// optionalPattern returns path part pattern of the Node marked optional, e.g. {id?:[0-9]+}
+func optionalPattern(node Node) string {
+	pattern := nodePattern(node)
+
+	end := len(node.Name()) + 1
+	if unwrapSubrouter(node).Kind() == KindCatchAll {
+		end++
+	}
+
+	return pattern[:end] + "?" + pattern[end:]
+}
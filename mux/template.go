@@ -0,0 +1,265 @@
+package mux
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vardius/gorouter/v4/context"
+	"github.com/vardius/gorouter/v4/middleware"
+	pathutils "github.com/vardius/gorouter/v4/path"
+)
+
+// templatePart is either literal or param of the template path part
+type templatePart struct {
+	literal string
+
+	name  string
+	exp   string
+	match func(string) bool // nil for params without regexp or constraint
+}
+
+func (p templatePart) isParam() bool {
+	return p.name != ""
+}
+
+// parseTemplate splits path part into literals and params, e.g. {name}.{ext},
+// params have to be separated with literals
+func parseTemplate(pathPart string) ([]templatePart, error) {
+	var parts []templatePart
+
+	for len(pathPart) > 0 {
+		start := strings.IndexByte(pathPart, '{')
+		if start < 0 {
+			parts = append(parts, templatePart{literal: pathPart})
+			break
+		}
+		if start > 0 {
+			parts = append(parts, templatePart{literal: pathPart[:start]})
+		}
+
+		end := pathutils.ClosingBrace(pathPart, start)
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed param in path part %s", pathPart)
+		}
+		if len(parts) > 0 && parts[len(parts)-1].isParam() {
+			return nil, fmt.Errorf("params of path part %s have to be separated with literals", pathPart)
+		}
+
+		param := pathPart[start : end+1]
+		if pathutils.IsCatchAll(param) {
+			return nil, fmt.Errorf("catch-all param can not be a part of path part template %s", pathPart)
+		}
+
+		name, exp := pathutils.GetNameFromPart(param)
+		part := templatePart{name: name, exp: exp}
+		if exp != "" {
+			if match, ok := lookupConstraint(exp); ok {
+				part.match = match
+			} else {
+				re, err := compileRegexp(exp)
+				if err != nil {
+					return nil, err
+				}
+				part.match = re.MatchString
+			}
+		}
+
+		parts = append(parts, part)
+		pathPart = pathPart[end+1:]
+	}
+
+	return parts, nil
+}
+
+func withTemplate(parent *staticNode, parts []templatePart) *templateNode {
+	n := &templateNode{
+		staticNode: parent,
+		parts:      parts,
+	}
+
+	for _, part := range parts {
+		if part.isParam() {
+			n.params++
+		}
+	}
+
+	return n
+}
+
+// templateNode matches path part combining literals with params, e.g. {name}.{ext} or v{version},
+// literals are compared, param values are validated with their regexps or constraints only
+type templateNode struct {
+	*staticNode
+
+	parts  []templatePart
+	params uint8
+}
+
+func (n *templateNode) Kind() Kind {
+	return KindTemplate
+}
+
+func (n *templateNode) MatchRoute(path string) (Route, context.Params) {
+	pathPart, subPath := pathutils.GetPart(path)
+	if !n.match(pathPart, nil, 0) {
+		return nil, nil
+	}
+
+	maxParamsSize := n.MaxParamsSize()
+
+	var route Route
+	var params context.Params
+
+	if subPath == "" || n.staticNode.skipSubPath {
+		route = n.route
+		params = make(context.Params, maxParamsSize)
+	} else {
+		route, params = n.children.MatchRoute(subPath)
+		if route == nil {
+			return nil, nil
+		}
+	}
+
+	// template params take the last indexes available for the node,
+	// they are set matching path part again so nothing is allocated for not matching paths
+	n.match(pathPart, params, maxParamsSize-n.params)
+
+	return route, params
+}
+
+func (n *templateNode) MatchMiddleware(path string) middleware.Collection {
+	pathPart, subPath := pathutils.GetPart(path)
+	if !n.match(pathPart, nil, 0) {
+		return nil
+	}
+
+	if subPath == "" || n.staticNode.skipSubPath {
+		return n.middleware
+	}
+
+	if treeMiddleware := n.children.MatchMiddleware(subPath); treeMiddleware != nil {
+		return n.middleware.Merge(treeMiddleware)
+	}
+
+	return n.middleware
+}
+
+// match matches path part against the template, param values are set to params
+// starting from the given index unless params are nil
+func (n *templateNode) match(pathPart string, params context.Params, index uint8) bool {
+	m := templateMatch{node: n, path: pathPart, params: params, index: index}
+
+	return m.from(0, 0, 0)
+}
+
+// templateMatch matches path part against template parts, param takes the longest value
+// allowing the rest of the path part to match
+type templateMatch struct {
+	node   *templateNode
+	path   string
+	params context.Params
+	index  uint8
+
+	// failed marks template parts which did not match the path from the offset,
+	// so params do not try them again, it is allocated with the first failure
+	failed []bool
+}
+
+// from matches path starting from the offset against template parts starting from the given one
+func (m *templateMatch) from(i, param, offset int) bool {
+	parts := m.node.parts
+	if i == len(parts) {
+		return offset == len(m.path)
+	}
+
+	part := parts[i]
+	if !part.isParam() {
+		if !m.node.hasLiteralPrefix(m.path[offset:], part.literal) {
+			return false
+		}
+
+		return m.from(i+1, param, offset+len(part.literal))
+	}
+
+	if i+1 == len(parts) {
+		value := m.path[offset:]
+		if value == "" || (part.match != nil && !part.match(value)) {
+			return false
+		}
+
+		m.set(param, part.name, value)
+		return true
+	}
+
+	next := parts[i+1].literal
+	for end := len(m.path) - len(next); end > offset; end-- {
+		if m.hasFailed(i+1, end) || !m.node.hasLiteralPrefix(m.path[end:], next) {
+			continue
+		}
+		if part.match != nil && !part.match(m.path[offset:end]) {
+			continue
+		}
+		if m.from(i+1, param+1, end) {
+			m.set(param, part.name, m.path[offset:end])
+			return true
+		}
+
+		m.fail(i+1, end)
+	}
+
+	return false
+}
+
+func (m *templateMatch) set(param int, name, value string) {
+	if m.params != nil {
+		m.params.Set(m.index+uint8(param), name, value)
+	}
+}
+
+func (m *templateMatch) hasFailed(i, offset int) bool {
+	return m.failed != nil && m.failed[i*(len(m.path)+1)+offset]
+}
+
+func (m *templateMatch) fail(i, offset int) {
+	if m.failed == nil {
+		m.failed = make([]bool, (len(m.node.parts)+1)*(len(m.path)+1))
+	}
+
+	m.failed[i*(len(m.path)+1)+offset] = true
+}
+
+func (n *templateNode) hasLiteralPrefix(pathPart, literal string) bool {
+	if len(pathPart) < len(literal) {
+		return false
+	}
+
+	if n.ignoreCase {
+		return strings.EqualFold(literal, pathPart[:len(literal)])
+	}
+
+	return literal == pathPart[:len(literal)]
+}
+
+// literalsLength is a number of characters template literals match
+func (n *templateNode) literalsLength() int {
+	length := 0
+	for _, part := range n.parts {
+		length += len(part.literal)
+	}
+
+	return length
+}
+
+// signature describes path parts matched by the template regardless of params names
+func (n *templateNode) signature() string {
+	var b strings.Builder
+	for _, part := range n.parts {
+		if part.isParam() {
+			b.WriteString("{:" + part.exp + "}")
+		} else {
+			b.WriteString(part.literal)
+		}
+	}
+
+	return b.String()
+}
@@ -29,8 +29,14 @@ func (t Tree) PrettyPrint() string {
 			_, _ = fmt.Fprintf(buff, "\t%s\n", node.Name())
 		case *wildcardNode:
 			_, _ = fmt.Fprintf(buff, "\t{%s}\n", node.Name())
+		case *constraintNode:
+			_, _ = fmt.Fprintf(buff, "\t{%s:%s}\n", node.Name(), node.constraint)
 		case *regexpNode:
-			_, _ = fmt.Fprintf(buff, "\t{%s:%s}\n", node.Name(), node.regexp.String())
+			_, _ = fmt.Fprintf(buff, "\t{%s:%s}\n", node.Name(), node.exp)
+		case *catchAllNode:
+			_, _ = fmt.Fprintf(buff, "\t{%s*}\n", node.Name())
+		case *templateNode:
+			_, _ = fmt.Fprintf(buff, "\t%s\n", node.Name())
 		case *subrouterNode:
 			_, _ = fmt.Fprintf(buff, "\t_%s\n", node.Name())
 		}
@@ -51,8 +57,13 @@ func (t Tree) Compile() Tree {
 		if len(child.Tree()) == 1 {
 			switch node := child.(type) {
 			case *staticNode:
+				if node.route != nil {
+					// node has to stay reachable under its own name
+					break
+				}
 				if staticNode, ok := node.Tree()[0].(*staticNode); ok {
 					node.WithChildren(staticNode.Tree())
+					node.WithRoute(staticNode.Route())
 					node.AppendMiddleware(staticNode.Middleware())
 					node.name = fmt.Sprintf("%s/%s", node.name, staticNode.name)
 
@@ -93,6 +104,110 @@ func (t Tree) MatchMiddleware(path string) middleware.Collection {
 	return treeMiddleware
 }
 
+// RouteMiddleware calls fn for every Route within the Tree with middleware collected
+// from the Nodes leading to it, prefixed with given collection.
+// Static is false when Nodes outside of that chain may contribute middleware
+// depending on the request path, in that case middleware has to be matched per request.
+func (t Tree) RouteMiddleware(m middleware.Collection, fn func(route Route, m middleware.Collection, static bool)) {
+	t.routeMiddleware(m, true, fn)
+}
+
+func (t Tree) routeMiddleware(m middleware.Collection, static bool, fn func(route Route, m middleware.Collection, static bool)) {
+	// branches holding middleware are found once per Tree, most of them hold none
+	var withMiddleware []Node
+	for _, child := range t {
+		if hasMiddleware(child) {
+			withMiddleware = append(withMiddleware, child)
+		}
+	}
+
+	for _, child := range t {
+		// always copy, collections are shared between nodes
+		chain := make(middleware.Collection, 0, len(m)+len(child.Middleware()))
+		chain = append(chain, m...)
+		chain = append(chain, child.Middleware()...)
+
+		childStatic := static && !overlapsWithMiddleware(child, withMiddleware)
+
+		if child.Route() != nil {
+			fn(child.Route(), chain, childStatic)
+		}
+
+		child.Tree().routeMiddleware(chain, childStatic, fn)
+	}
+}
+
+// overlapsWithMiddleware checks if any other of the Nodes holding middleware within their branches
+// can match the same path part as given Node
+func overlapsWithMiddleware(node Node, withMiddleware []Node) bool {
+	static, isStatic := unwrapSubrouter(node).(*staticNode)
+
+	for _, other := range withMiddleware {
+		if other == node {
+			continue
+		}
+
+		if otherStatic, ok := unwrapSubrouter(other).(*staticNode); ok && isStatic && !staticNodesOverlap(static, otherStatic) {
+			continue
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// staticNodesOverlap checks if static nodes can match the same path part, which happens
+// when one of them is compiled from the other or their names differ only in case when case is ignored
+func staticNodesOverlap(a, b *staticNode) bool {
+	left, right := a.name, b.name
+	if a.ignoreCase || b.ignoreCase {
+		left, right = strings.ToLower(left), strings.ToLower(right)
+	}
+
+	return left == right || strings.HasPrefix(left, right+"/") || strings.HasPrefix(right, left+"/")
+}
+
+func hasMiddleware(node Node) bool {
+	if len(node.Middleware()) > 0 {
+		return true
+	}
+
+	for _, child := range node.Tree() {
+		if hasMiddleware(child) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func unwrapSubrouter(node Node) Node {
+	if n, ok := node.(*subrouterNode); ok {
+		return n.Node
+	}
+
+	return node
+}
+
+// IgnoreCase makes static Nodes of the whole Tree match path case-insensitively
+func (t Tree) IgnoreCase() {
+	for _, child := range t {
+		child.IgnoreCase()
+		child.Tree().IgnoreCase()
+	}
+}
+
+// IgnorePathCase makes static Nodes created for the given path pattern match path case-insensitively,
+// Nodes added to the Tree which already ignores case can be updated without walking the whole Tree
+func (t Tree) IgnorePathCase(path string) {
+	for _, p := range pathutils.ExpandOptional(path) {
+		for _, node := range t.findPath(pathutils.TrimSlash(p), nil) {
+			node.IgnoreCase()
+		}
+	}
+}
+
 // Find finds Node inside a tree by name
 func (t Tree) Find(name string) Node {
 	if name == "" {
@@ -109,13 +224,22 @@ func (t Tree) Find(name string) Node {
 }
 
 // WithRoute returns new Tree with Route set to Node
-// Route is set to Node under the give path, if Node does not exist it is created
+// Route is set to Node under the give path, if Node does not exist it is created,
+// path with optional wildcards (e.g. reports/{year?}) sets Route to Node of every expanded path
 func (t Tree) WithRoute(path string, route Route, maxParamsSize uint8) Tree {
 	path = pathutils.TrimSlash(path)
 	if path == "" {
 		return t
 	}
 
+	if paths := pathutils.ExpandOptional(path); len(paths) > 1 {
+		for _, p := range paths {
+			t = t.WithRoute(p, route, maxParamsSize)
+		}
+
+		return t
+	}
+
 	parts := strings.Split(path, "/")
 	name, _ := pathutils.GetNameFromPart(parts[0])
 	node := t.Find(name)
@@ -181,6 +305,14 @@ func (t Tree) WithSubrouter(path string, route Route, maxParamsSize uint8) Tree
 			node = withSubrouter(node)
 		}
 		newTree = t.withNode(node).sort()
+	} else if _, ok := node.(*subrouterNode); !ok && len(parts) == 1 {
+		// node created for middleware only
+		for i, child := range t {
+			if child == node {
+				node = withSubrouter(node)
+				t[i] = node
+			}
+		}
 	}
 
 	if len(parts) == 1 {
@@ -203,9 +335,9 @@ func (t Tree) withNode(node Node) Tree {
 	return newTree
 }
 
-// Sort sorts nodes in order: static, regexp, wildcard
+// Sort sorts nodes in order: static, template, constraint, regexp, wildcard, catch-all
 func (t Tree) sort() Tree {
-	// Sort Nodes in order [statics, regexps, wildcards]
+	// Sort Nodes in order [statics, templates, constraints, regexps, wildcards, catch-alls]
 	sort.SliceStable(t, func(i, j int) bool {
 		return isMoreImportant(t[i], t[j])
 	})
@@ -213,6 +345,7 @@ func (t Tree) sort() Tree {
 	return t
 }
 
+// templates are matched before any params, as they match only path parts with their literals
 func isMoreImportant(left Node, right Node) bool {
 	if leftNode, ok := left.(*subrouterNode); ok {
 		return isMoreImportant(leftNode.Node, right)
@@ -228,16 +361,240 @@ func isMoreImportant(left Node, right Node) bool {
 			return len(leftNode.name) < len(rightNode.name)
 		}
 		return true
-	case *regexpNode:
-		if _, ok := right.(*wildcardNode); ok {
+	case *templateNode:
+		switch rightNode := right.(type) {
+		case *templateNode:
+			return leftNode.literalsLength() > rightNode.literalsLength()
+		case *constraintNode, *regexpNode, *wildcardNode, *catchAllNode:
 			return true
 		}
-		if rightNode, ok := right.(*regexpNode); ok {
+		return false
+	case *constraintNode:
+		switch right.(type) {
+		case *regexpNode, *wildcardNode, *catchAllNode:
+			return true
+		}
+		return false
+	case *regexpNode:
+		switch rightNode := right.(type) {
+		case *wildcardNode, *catchAllNode:
+			return true
+		case *regexpNode:
 			return len(leftNode.regexp.String()) < len(rightNode.regexp.String())
 		}
 		return false
-		// case *wildcardNode:
+	case *wildcardNode:
+		_, ok := right.(*catchAllNode)
+		return ok
+		// case *catchAllNode:
 	}
 
 	return false
 }
+
+// Clone returns deep copy of the Tree, Nodes can be changed without affecting the original one,
+// Routes and middleware collections are shared
+func (t Tree) Clone() Tree {
+	if t == nil {
+		return nil
+	}
+
+	clone := make(Tree, len(t))
+	for i, child := range t {
+		clone[i] = cloneNode(child)
+	}
+
+	return clone
+}
+
+// cloneNode copies Node with its Tree, unknown Node implementations are not copied
+func cloneNode(node Node) Node {
+	switch n := node.(type) {
+	case *staticNode:
+		return cloneStaticNode(n)
+	case *wildcardNode:
+		return &wildcardNode{staticNode: cloneStaticNode(n.staticNode)}
+	case *regexpNode:
+		clone := *n
+		clone.staticNode = cloneStaticNode(n.staticNode)
+		return &clone
+	case *constraintNode:
+		clone := *n
+		clone.staticNode = cloneStaticNode(n.staticNode)
+		return &clone
+	case *catchAllNode:
+		return &catchAllNode{staticNode: cloneStaticNode(n.staticNode)}
+	case *templateNode:
+		clone := *n
+		clone.staticNode = cloneStaticNode(n.staticNode)
+		return &clone
+	case *subrouterNode:
+		return &subrouterNode{Node: cloneNode(n.Node)}
+	}
+
+	return node
+}
+
+func cloneStaticNode(n *staticNode) *staticNode {
+	clone := *n
+	clone.children = n.children.Clone()
+
+	return &clone
+}
+
+// FindNode returns Node created for the given path pattern, nil if there is none,
+// static Nodes compiled into single one are found by any of their paths
+func (t Tree) FindNode(path string) Node {
+	// Route of path with optional wildcards is found under the longest expanded path
+	paths := pathutils.ExpandOptional(path)
+
+	path = pathutils.TrimSlash(paths[len(paths)-1])
+	if path == "" {
+		return nil
+	}
+
+	return t.findNode(path)
+}
+
+func (t Tree) findNode(path string) Node {
+	if nodes := t.findPath(path, nil); nodes != nil {
+		return nodes[len(nodes)-1]
+	}
+
+	return nil
+}
+
+// findPath returns chain of Nodes created for the given path pattern appended to parent ones,
+// nil if there is none
+func (t Tree) findPath(path string, parent []Node) []Node {
+	for _, child := range t {
+		subPath, ok := consumePart(child, path)
+		if !ok {
+			continue
+		}
+
+		nodes := append(parent[:len(parent):len(parent)], child)
+		if subPath == "" {
+			return nodes
+		}
+		if nodes = child.Tree().findPath(subPath, nodes); nodes != nil {
+			return nodes
+		}
+	}
+
+	return nil
+}
+
+// FindRoute returns Route set to Node created for the given path pattern, nil if there is none
+func (t Tree) FindRoute(path string) Route {
+	if node := t.FindNode(path); node != nil {
+		return node.Route()
+	}
+
+	return nil
+}
+
+// WithoutRoute returns new Tree with Route removed from Node created for the given path pattern,
+// Nodes left without Routes within their Trees are removed together with their middleware
+func (t Tree) WithoutRoute(path string) Tree {
+	path = pathutils.TrimSlash(path)
+	if path == "" {
+		return t
+	}
+
+	if paths := pathutils.ExpandOptional(path); len(paths) > 1 {
+		// the longest path first, so Nodes of shorter ones can be removed once left empty
+		for i := len(paths) - 1; i >= 0; i-- {
+			t = t.WithoutRoute(paths[i])
+		}
+
+		return t
+	}
+
+	for i, child := range t {
+		subPath, ok := consumePart(child, path)
+		if !ok {
+			continue
+		}
+
+		if subPath == "" {
+			if child.Route() == nil {
+				continue
+			}
+
+			child.WithRoute(nil)
+			child = withoutSubrouter(child)
+		} else {
+			if node := child.Tree().findNode(subPath); node == nil || node.Route() == nil {
+				continue
+			}
+
+			child.WithChildren(child.Tree().WithoutRoute(subPath))
+		}
+
+		if firstRoute(child) == nil {
+			// always copy, removed Node must not be overwritten in the original Tree
+			return append(t[:i:i], t[i+1:]...)
+		}
+
+		t[i] = child
+
+		return t
+	}
+
+	return t
+}
+
+// withoutSubrouter turns Subrouter Node without Route back to regular Node,
+// so Routes can be added to its Tree
+func withoutSubrouter(node Node) Node {
+	n, ok := node.(*subrouterNode)
+	if !ok || n.Route() != nil {
+		return node
+	}
+
+	switch inner := n.Node.(type) {
+	case *staticNode:
+		inner.skipSubPath = false
+	case *wildcardNode:
+		inner.skipSubPath = false
+	case *regexpNode:
+		inner.skipSubPath = false
+	case *constraintNode:
+		inner.skipSubPath = false
+	case *catchAllNode:
+		inner.skipSubPath = false
+	case *templateNode:
+		inner.skipSubPath = false
+	}
+
+	return n.Node
+}
+
+// consumePart checks if path pattern starts with the part Node was created for
+// and returns the rest of the path
+func consumePart(node Node, path string) (string, bool) {
+	switch unwrapSubrouter(node).Kind() {
+	case KindStatic:
+	case KindTemplate:
+		part, subPath := pathutils.GetPart(path)
+
+		return subPath, node.Name() == part
+	default:
+		part, subPath := pathutils.GetPart(path)
+		name, _ := pathutils.GetNameFromPart(part)
+
+		return subPath, name != part && node.Name() == name
+	}
+
+	// static Nodes may be compiled into single Node named with multiple path parts
+	name := node.Name()
+	if path == name {
+		return "", true
+	}
+	if strings.HasPrefix(path, name+"/") {
+		return path[len(name)+1:], true
+	}
+
+	return "", false
+}
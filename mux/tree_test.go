@@ -1,7 +1,14 @@
 package mux
 
 import (
+	"errors"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/vardius/gorouter/v4/context"
+	"github.com/vardius/gorouter/v4/middleware"
 )
 
 func TestTreeMatch(t *testing.T) {
@@ -79,3 +86,620 @@ func TestTreeFindNode(t *testing.T) {
 	}
 }
 
+func TestTreeURL(t *testing.T) {
+	route := newMockRoute("testroute")
+
+	tree := NewTree().WithRoute("blog/{lang:en|pl}/posts/{postId}", route, 0)
+
+	url, err := tree.URL("blog/{lang:en|pl}/posts/{postId}", context.Params{{Key: "lang", Value: "pl"}, {Key: "postId", Value: "1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if url != "/blog/pl/posts/1" {
+		t.Errorf("expected %s, got %s", "/blog/pl/posts/1", url)
+	}
+
+	if _, err := tree.URL("blog/{lang:en|pl}/posts/{postId}", context.Params{{Key: "lang", Value: "de"}, {Key: "postId", Value: "1"}}); err == nil {
+		t.Error("expected error for value not matching regexp")
+	}
+
+	if _, err := tree.URL("blog/{lang:en|pl}", nil); err == nil {
+		t.Error("expected error for unknown route")
+	}
+
+	if _, err := tree.URL("blog/{lang:en|pl}/posts/{postId}", context.Params{{Key: "lang", Value: "pl"}, {Key: "postId", Value: "1"}, {Key: "page", Value: "2"}}); err == nil {
+		t.Error("expected error for param not used by the route")
+	}
+
+	files := newMockRoute("files")
+	tree = tree.WithRoute("files/{path*}", files, 0)
+
+	if url, err := tree.URL("files/{path*}", context.Params{{Key: "path", Value: "/a b/c?.txt"}}); err != nil || url != "/files/a%20b/c%3F.txt" {
+		t.Errorf("expected catch-all value parts to be escaped, got %s %v", url, err)
+	}
+}
+
+func TestTreeIgnoreCase(t *testing.T) {
+	route := newMockRoute("testroute")
+	m := middleware.NewCollection(middleware.WrapperFunc(func(h middleware.Handler) middleware.Handler { return h }))
+
+	tree := NewTree().WithRoute("api/users/{name}", route, 0)
+	tree = tree.WithMiddleware("api", m, 0)
+
+	if r, _ := tree.MatchRoute("API/Users/John"); r != nil {
+		t.Fatal("route should not match path in different case")
+	}
+
+	tree.IgnoreCase()
+	tree = tree.Compile()
+
+	r, params := tree.MatchRoute("API/Users/John")
+	if r != route {
+		t.Fatalf("route did not match path in different case")
+	}
+	if params.Value("name") != "John" {
+		t.Errorf("expected param value %s, got %s", "John", params.Value("name"))
+	}
+	if len(tree.MatchMiddleware("API/USERS/John")) != 1 {
+		t.Error("middleware did not match path in different case")
+	}
+
+	url, err := tree.URL("api/users/{name}", params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "/api/users/John" {
+		t.Errorf("expected %s, got %s", "/api/users/John", url)
+	}
+}
+
+func TestTreeRoutes(t *testing.T) {
+	userRoute := newMockRoute("user")
+	postRoute := newMockRoute("post")
+	filesRoute := newMockRoute("files")
+	reportsRoute := newMockRoute("reports")
+	m := middleware.NewCollection(middleware.WrapperFunc(func(h middleware.Handler) middleware.Handler { return h }))
+
+	tree := NewTree()
+	tree = tree.WithRoute("users/{id:int}", userRoute, 0)
+	tree = tree.WithRoute("users/{id:int}/posts/{slug:[a-z-]+}", postRoute, 0)
+	tree = tree.WithSubrouter("files", filesRoute, 0)
+	tree = tree.WithRoute("reports/{year?:[0-9]+}/{path*?}", reportsRoute, 0)
+	tree = tree.WithMiddleware("users", m, 0)
+	tree = tree.WithMiddleware("users/{id:int}/posts", m, 0)
+
+	want := []RouteInfo{
+		{Route: userRoute, Pattern: "/users/{id:int}", Params: []string{"id"}, Constraints: map[string]string{"id": "int"}, Middleware: 1},
+		{Route: postRoute, Pattern: "/users/{id:int}/posts/{slug:[a-z-]+}", Params: []string{"id", "slug"}, Constraints: map[string]string{"id": "int", "slug": "[a-z-]+"}, Middleware: 2},
+		{Route: filesRoute, Pattern: "/files", Subrouter: true},
+		{Route: reportsRoute, Pattern: "/reports/{year?:[0-9]+}/{path*?}", Params: []string{"year", "path"}, Constraints: map[string]string{"year": "[0-9]+"}},
+	}
+
+	if got := tree.Routes(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected routes %+v, got %+v", want, got)
+	}
+}
+
+func TestTreeCompileKeepsMiddleware(t *testing.T) {
+	label := func(name string) middleware.Collection {
+		return middleware.NewCollection(middleware.WrapperFunc(func(h middleware.Handler) middleware.Handler {
+			return name + h.(string)
+		}))
+	}
+
+	paths := []string{"x", "xy", "x/y", "xy/z", "a/b/c", "a/bc"}
+	build := func() Tree {
+		tree := NewTree()
+		for _, path := range paths {
+			tree = tree.WithRoute(path, newMockRoute(path), 0)
+		}
+		tree = tree.WithMiddleware("x", label("[x]"), 0)
+		tree = tree.WithMiddleware("a/b", label("[ab]"), 0)
+
+		return tree
+	}
+
+	tree, compiled := build(), build().Compile()
+
+	precomputed := make(map[string]string)
+	compiled.RouteMiddleware(nil, func(r Route, m middleware.Collection, static bool) {
+		if static {
+			precomputed[r.Handler().(string)] = m.Compose("").(string)
+		}
+	})
+
+	want := map[string]string{"x": "[x]", "xy": "", "x/y": "[x]", "xy/z": "", "a/b/c": "[ab]", "a/bc": ""}
+	for _, path := range paths {
+		if got := tree.MatchMiddleware(path).Compose(""); got != want[path] {
+			t.Errorf("%s: expected middleware %q, got %q", path, want[path], got)
+		}
+		if got := compiled.MatchMiddleware(path).Compose(""); got != want[path] {
+			t.Errorf("%s: expected compiled tree middleware %q, got %q", path, want[path], got)
+		}
+		if got, ok := precomputed[path]; !ok || got != want[path] {
+			t.Errorf("%s: expected precomputed middleware %q, got %q", path, want[path], got)
+		}
+	}
+}
+
+func TestTreeCompileKeepsRoutes(t *testing.T) {
+	xRoute := newMockRoute("x")
+	xyzRoute := newMockRoute("xyz")
+
+	tree := NewTree().
+		WithRoute("x", xRoute, 0).
+		WithRoute("x/y/z", xyzRoute, 0).
+		Compile()
+
+	tests := []struct {
+		path     string
+		expected Route
+	}{
+		{"x", xRoute},
+		{"x/y", nil},
+		{"x/y/z", xyzRoute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if route, _ := tree.MatchRoute(tt.path); route != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, route)
+			}
+		})
+	}
+}
+
+func TestTreeRouteMiddleware(t *testing.T) {
+	m1 := buildMockMiddlewareFunc("1")
+	m2 := buildMockMiddlewareFunc("2")
+	m3 := buildMockMiddlewareFunc("3")
+
+	staticRoute := newMockRoute("static")
+	paramRoute := newMockRoute("param")
+	otherRoute := newMockRoute("other")
+
+	tree := NewTree().
+		WithRoute("x/y", staticRoute, 0).
+		WithRoute("x/{param}", paramRoute, 0).
+		WithRoute("z", otherRoute, 0).
+		WithMiddleware("x", middleware.NewCollection(m1), 0).
+		WithMiddleware("x/{param}", middleware.NewCollection(m2), 0).
+		WithMiddleware("z", middleware.NewCollection(m3), 0)
+
+	type result struct {
+		length int
+		static bool
+	}
+
+	expected := map[Route]result{
+		staticRoute: {2, false}, // {param} node matches y as well
+		paramRoute:  {3, true},
+		otherRoute:  {2, true},
+	}
+
+	tree.RouteMiddleware(middleware.NewCollection(m1), func(route Route, m middleware.Collection, static bool) {
+		want, ok := expected[route]
+		if !ok {
+			t.Fatalf("unexpected route %v", route)
+		}
+		if len(m) != want.length || static != want.static {
+			t.Errorf("route %v: expected %v, got {%d %v}", route, want, len(m), static)
+		}
+	})
+}
+
+func TestWalk(t *testing.T) {
+	tree := NewTree().
+		WithRoute("x/{a}/y", newMockRoute("y"), 0).
+		WithRoute("x/{a}/z", newMockRoute("z"), 0).
+		WithSubrouter("w", newMockRoute("w"), 0)
+
+	type visit struct {
+		path string
+		kind Kind
+	}
+
+	var got []visit
+	err := Walk(tree, func(path []Node, n Node) error {
+		var p string
+		for _, parent := range path {
+			p += parent.Name() + "/"
+		}
+		got = append(got, visit{p + n.Name(), n.Kind()})
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	want := []visit{
+		{"x", KindStatic},
+		{"x/a", KindWildcard},
+		{"x/a/y", KindStatic},
+		{"x/a/z", KindStatic},
+		{"w", KindSubrouter},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected visits %v, got %v", want, got)
+	}
+
+	var names []string
+	err = Walk(tree, func(path []Node, n Node) error {
+		names = append(names, n.Name())
+		if n.Kind() == KindWildcard {
+			return SkipChildren
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if want := []string{"x", "a", "w"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("expected visits %v when skipping children, got %v", want, names)
+	}
+
+	stop := errors.New("stop")
+	names = nil
+	err = Walk(tree, func(path []Node, n Node) error {
+		names = append(names, n.Name())
+		if n.Name() == "y" {
+			return stop
+		}
+
+		return nil
+	})
+	if err != stop {
+		t.Errorf("expected error %v, got %v", stop, err)
+	}
+	if want := []string{"x", "a", "y"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("expected visits %v until error, got %v", want, names)
+	}
+}
+
+func TestTreeCheckRoute(t *testing.T) {
+	usersRoute := newMockRoute("users")
+	filesRoute := newMockRoute("files")
+
+	tree := NewTree().
+		WithRoute("users/{id}", usersRoute, 0).
+		WithRoute("posts/{slug:[a-z]+}", newMockRoute("posts"), 0).
+		WithRoute("tags/{tag:int}", newMockRoute("tags"), 0).
+		WithSubrouter("files", filesRoute, 0).
+		WithMiddleware("comments/{id}", middleware.NewCollection(), 0)
+
+	tests := []struct {
+		name      string
+		path      string
+		subrouter bool
+		existing  string
+		route     Route
+	}{
+		{"new route", "users/{id}/posts", false, "", nil},
+		{"param referenced by name", "posts/{slug}/comments", false, "", nil},
+		{"different regexp", "posts/{slug:[0-9]+}", false, "posts/{slug:[a-z]+}", nil},
+		{"different constraint", "tags/{tag:uuid}", false, "tags/{tag:int}", nil},
+		{"static named as param", "users/id", false, "users/{id}", usersRoute},
+		{"duplicate route", "users/{id}", false, "users/{id}", usersRoute},
+		{"ambiguous wildcard", "users/{name}", false, "users/{id}", usersRoute},
+		{"ambiguous regexp", "posts/{title:[a-z]+}", false, "posts/{slug:[a-z]+}", nil},
+		{"ambiguous wildcard without route", "comments/{slug}", false, "", nil},
+		{"shadowed by subrouter", "files/x", false, "files", filesRoute},
+		{"duplicate subrouter", "files", true, "files", filesRoute},
+		{"subrouter shadows route", "users", true, "users", usersRoute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			if tt.subrouter {
+				err = tree.CheckSubrouter(tt.path)
+			} else {
+				err = tree.CheckRoute(tt.path)
+			}
+
+			if tt.existing == "" {
+				if err != nil {
+					t.Errorf("unexpected error %v", err)
+				}
+				return
+			}
+
+			var conflict *ConflictError
+			if !errors.As(err, &conflict) {
+				t.Fatalf("expected conflict error, got %v", err)
+			}
+			if conflict.Path != tt.path || conflict.Existing != tt.existing {
+				t.Errorf("expected conflict of %s with %s, got %s with %s", tt.path, tt.existing, conflict.Path, conflict.Existing)
+			}
+			if tt.route != nil && conflict.Route != tt.route {
+				t.Errorf("expected conflicting route %v, got %v", tt.route, conflict.Route)
+			}
+		})
+	}
+}
+
+func TestTreeWithoutRoute(t *testing.T) {
+	xRoute := newMockRoute("x")
+	xyzRoute := newMockRoute("xyz")
+	idRoute := newMockRoute("id")
+
+	tree := NewTree().
+		WithRoute("x", xRoute, 0).
+		WithRoute("x/y/z", xyzRoute, 0).
+		WithRoute("x/{id:[0-9]+}", idRoute, 0).
+		Compile()
+
+	if route := tree.FindRoute("x/{id:[0-9]+}"); route != idRoute {
+		t.Fatalf("expected %v, got %v", idRoute, route)
+	}
+
+	tree = tree.WithoutRoute("x/y/z")
+	if route, _ := tree.MatchRoute("x/y/z"); route != nil {
+		t.Errorf("expected removed route not to match, got %v", route)
+	}
+	if node := tree.Find("x").Tree().Find("y"); node != nil {
+		t.Errorf("expected empty nodes to be removed, got %s", node.Name())
+	}
+
+	tree = tree.WithoutRoute("x")
+	if route, _ := tree.MatchRoute("x/1"); route != idRoute {
+		t.Errorf("expected %v, got %v", idRoute, route)
+	}
+
+	tree = tree.WithoutRoute("x/{id}")
+	if len(tree) != 0 {
+		t.Errorf("expected empty tree, got %d nodes", len(tree))
+	}
+}
+
+func TestTreeClone(t *testing.T) {
+	xRoute := newMockRoute("x")
+	yRoute := newMockRoute("y")
+
+	tree := NewTree().WithRoute("x/{id}", xRoute, 0)
+	clone := tree.Clone().
+		WithRoute("x/{id}/y", yRoute, 0).
+		WithoutRoute("x/{id}")
+
+	if route, _ := tree.MatchRoute("x/1"); route != xRoute {
+		t.Errorf("expected original tree to keep %v, got %v", xRoute, route)
+	}
+	if route, _ := tree.MatchRoute("x/1/y"); route != nil {
+		t.Errorf("expected original tree not to match %v", route)
+	}
+	if route, params := clone.MatchRoute("x/1/y"); route != yRoute || params.Value("id") != "1" {
+		t.Errorf("expected clone to match %v with params, got %v %v", yRoute, route, params)
+	}
+	if route, _ := clone.MatchRoute("x/1"); route != nil {
+		t.Errorf("expected clone not to match %v", route)
+	}
+}
+
+func TestTreeWithoutRouteDropsOrphanedMiddleware(t *testing.T) {
+	m := middleware.Collection{buildMockMiddlewareFunc("1")}
+	xRoute := newMockRoute("x")
+	yRoute := newMockRoute("y")
+
+	tree := NewTree().
+		WithMiddleware("admin", m, 0).
+		WithRoute("admin/x", xRoute, 0).
+		WithMiddleware("public", m, 0).
+		WithRoute("public/y", yRoute, 0).
+		WithMiddleware("other", m, 0)
+
+	tree = tree.WithoutRoute("admin/x")
+
+	if node := tree.Find("admin"); node != nil {
+		t.Errorf("expected node left without routes to be removed with its middleware, got %s", node.Name())
+	}
+	if node := tree.Find("public"); node == nil || len(node.Middleware()) != 1 {
+		t.Error("expected middleware of the node with routes to be kept")
+	}
+	if node := tree.Find("other"); node == nil {
+		t.Error("expected middleware registered outside of the removed route path to be kept")
+	}
+}
+
+func TestTreeFindNodeCompiled(t *testing.T) {
+	xyzRoute := newMockRoute("xyz")
+	xywRoute := newMockRoute("xyw")
+
+	// route added after Compile creates static Node next to the compiled one
+	tree := NewTree().
+		WithRoute("x/y/z", xyzRoute, 0).
+		Compile().
+		WithRoute("x/y/w", xywRoute, 0)
+
+	if route := tree.FindRoute("x/y/z"); route != xyzRoute {
+		t.Errorf("expected %v, got %v", xyzRoute, route)
+	}
+	if route := tree.FindRoute("x/y/w"); route != xywRoute {
+		t.Errorf("expected %v, got %v", xywRoute, route)
+	}
+
+	tree = tree.WithoutRoute("x/y/z")
+	if route, _ := tree.MatchRoute("x/y/z"); route != nil {
+		t.Errorf("expected removed route not to match, got %v", route)
+	}
+	if route, _ := tree.MatchRoute("x/y/w"); route != xywRoute {
+		t.Errorf("expected %v, got %v", xywRoute, route)
+	}
+}
+
+func TestTreeWithRouteOptional(t *testing.T) {
+	route := newMockRoute("reports")
+	tree := NewTree().WithRoute("reports/{year?:[0-9]+}/{month?}", route, 0)
+
+	tests := []struct {
+		path   string
+		params context.Params
+	}{
+		{"reports", context.Params{}},
+		{"reports/2024", context.Params{{Key: "year", Value: "2024"}}},
+		{"reports/2024/05", context.Params{{Key: "year", Value: "2024"}, {Key: "month", Value: "05"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			matched, params := tree.MatchRoute(tt.path)
+			if matched != route {
+				t.Fatalf("expected %v, got %v", route, matched)
+			}
+			if !reflect.DeepEqual(params, tt.params) {
+				t.Errorf("expected params %v, got %v", tt.params, params)
+			}
+		})
+	}
+
+	if matched, _ := tree.MatchRoute("reports/latest"); matched != nil {
+		t.Errorf("expected regexp of optional wildcard to be validated, got %v", matched)
+	}
+
+	urls := []struct {
+		params context.Params
+		url    string
+	}{
+		{nil, "/reports"},
+		{context.Params{{Key: "year", Value: "2024"}}, "/reports/2024"},
+		{context.Params{{Key: "year", Value: "2024"}, {Key: "month", Value: "05"}}, "/reports/2024/05"},
+	}
+
+	for _, tt := range urls {
+		if url, err := tree.URL("reports/{year?:[0-9]+}/{month?}", tt.params); err != nil || url != tt.url {
+			t.Errorf("expected %s, got %s %v", tt.url, url, err)
+		}
+	}
+
+	if node := tree.FindNode("reports/{year?:[0-9]+}/{month?}"); node == nil || node.Name() != "month" {
+		t.Errorf("expected node of the longest path, got %v", node)
+	}
+
+	if tree = tree.WithoutRoute("reports/{year?:[0-9]+}/{month?}"); len(tree) != 0 {
+		t.Errorf("expected empty tree, got %d nodes", len(tree))
+	}
+}
+
+func TestTreeWithRouteTemplate(t *testing.T) {
+	files := newMockRoute("files")
+	version := newMockRoute("version")
+	image := newMockRoute("image")
+	name := newMockRoute("name")
+
+	tree := NewTree().
+		WithRoute("files/{name}.{ext}", files, 0).
+		WithRoute("api/v{version:uint}/users", version, 0).
+		WithRoute("images/{id:int}.png", image, 0).
+		WithRoute("images/{name}", name, 0)
+
+	tests := []struct {
+		path   string
+		route  Route
+		params context.Params
+	}{
+		{"files/archive.tar.gz", files, context.Params{{Key: "name", Value: "archive.tar"}, {Key: "ext", Value: "gz"}}},
+		{"api/v2/users", version, context.Params{{Key: "version", Value: "2"}}},
+		{"images/42.png", image, context.Params{{Key: "id", Value: "42"}}},
+		{"images/logo.png", name, context.Params{{Key: "name", Value: "logo.png"}}},
+		{"files/archive", nil, nil},
+		{"files/.gz", nil, nil},
+		{"api/vx/users", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			matched, params := tree.MatchRoute(tt.path)
+			if matched != tt.route {
+				t.Fatalf("expected %v, got %v", tt.route, matched)
+			}
+			if !reflect.DeepEqual(params, tt.params) {
+				t.Errorf("expected params %v, got %v", tt.params, params)
+			}
+		})
+	}
+
+	if url, err := tree.URL("files/{name}.{ext}", context.Params{{Key: "name", Value: "report"}, {Key: "ext", Value: "pdf"}}); err != nil || url != "/files/report.pdf" {
+		t.Errorf("expected /files/report.pdf, got %s %v", url, err)
+	}
+	if _, err := tree.URL("images/{id:int}.png", context.Params{{Key: "id", Value: "logo"}}); err == nil {
+		t.Error("expected constraint of template param to be validated")
+	}
+
+	found := false
+	for _, info := range tree.Routes() {
+		if info.Route != version {
+			continue
+		}
+		found = true
+		if info.Pattern != "/api/v{version:uint}/users" || !reflect.DeepEqual(info.Params, []string{"version"}) || info.Constraints["version"] != "uint" {
+			t.Errorf("unexpected route info %+v", info)
+		}
+	}
+	if !found {
+		t.Error("expected template route to be listed")
+	}
+
+	if err := tree.CheckRoute("files/{file}.{type}"); err == nil {
+		t.Error("expected template matching the same values to conflict")
+	}
+	if node := tree.FindNode("files/{name}.{ext}"); node == nil || node.Kind() != KindTemplate {
+		t.Errorf("expected template node, got %v", node)
+	}
+}
+
+func TestTreeTemplateAdjacentParams(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected adjacent params to panic")
+		}
+	}()
+
+	NewTree().WithRoute("files/{name}{ext}", newMockRoute("files"), 0)
+}
+
+func TestTreeTemplateLongPathPart(t *testing.T) {
+	route := newMockRoute("t")
+	tree := NewTree().WithRoute("t/{a}-{b}-{c}-{d}.x", route, 0)
+
+	start := time.Now()
+	if r, _ := tree.MatchRoute("t/" + strings.Repeat("-", 2000) + ".y"); r != nil {
+		t.Errorf("expected path part without template suffix not to match, got %v", r)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected params not to backtrack over the same path part offsets, took %s", elapsed)
+	}
+
+	r, params := tree.MatchRoute("t/" + strings.Repeat("a-", 1000) + "b.x")
+	if r != route || params.Value("a") != strings.TrimSuffix(strings.Repeat("a-", 998), "-") || params.Value("d") != "b" {
+		t.Errorf("expected leading params to take the longest values, got %v %v", r, params)
+	}
+}
+
+func TestTreeTemplateAllocs(t *testing.T) {
+	tree := NewTree().WithRoute("files/{name}.{ext}", newMockRoute("files"), 0)
+
+	if allocs := testing.AllocsPerRun(100, func() {
+		tree.MatchRoute("files/archive")
+		tree.MatchMiddleware("files/archive.tar.gz")
+	}); allocs != 0 {
+		t.Errorf("expected template to be matched without allocations, got %v", allocs)
+	}
+}
+
+func TestTreeIgnorePathCase(t *testing.T) {
+	users := newMockRoute("users")
+	posts := newMockRoute("posts")
+
+	tree := NewTree().WithRoute("api/users/{name}", users, 0)
+	tree = tree.WithRoute("api/posts", posts, 0)
+	tree.IgnorePathCase("api/users/{name}")
+
+	if r, _ := tree.MatchRoute("API/USERS/John"); r != users {
+		t.Errorf("expected nodes of the path to ignore case, got %v", r)
+	}
+	if r, _ := tree.MatchRoute("api/POSTS"); r != nil {
+		t.Errorf("expected nodes of other paths to match case, got %v", r)
+	}
+}
@@ -0,0 +1,166 @@
+package mux
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/vardius/gorouter/v4/context"
+	pathutils "github.com/vardius/gorouter/v4/path"
+)
+
+// URL builds path of the Node created for the given path pattern within the Tree
+// wildcard values are taken from params, regexp values are validated against node regexp
+// and percent-encoded, params not used by any wildcard are reported as error,
+// path with optional wildcards is built from the longest expanded path all params are given for
+func (t Tree) URL(path string, params context.Params) (string, error) {
+	var nodes []Node
+	for _, p := range pathutils.ExpandOptional(path) {
+		p = pathutils.TrimSlash(p)
+		if p == "" {
+			continue
+		}
+
+		// expanded paths go from the shortest to the longest one
+		if found := t.findPath(p, nil); found != nil && (nodes == nil || hasParams(found, params)) {
+			nodes = found
+		}
+	}
+
+	if nodes == nil || nodes[len(nodes)-1].Route() == nil {
+		return "", fmt.Errorf("route not found in tree")
+	}
+
+	for _, param := range params {
+		if !usesParam(nodes, param.Key) {
+			return "", fmt.Errorf("param %q is not used by the route", param.Key)
+		}
+	}
+
+	var b strings.Builder
+	for _, node := range nodes {
+		part, err := urlPart(node, params)
+		if err != nil {
+			return "", err
+		}
+
+		b.WriteByte('/')
+		b.WriteString(part)
+	}
+
+	return b.String(), nil
+}
+
+// hasParams checks if params hold values for all wildcards of the Nodes chain
+func hasParams(nodes []Node, params context.Params) bool {
+	for _, node := range nodes {
+		switch n := unwrapSubrouter(node).(type) {
+		case *staticNode:
+		case *templateNode:
+			for _, part := range n.parts {
+				if part.isParam() && params.Value(part.name) == "" {
+					return false
+				}
+			}
+		default:
+			if params.Value(node.Name()) == "" {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// usesParam checks if any wildcard of the Nodes chain is named with given key
+func usesParam(nodes []Node, key string) bool {
+	for _, node := range nodes {
+		switch n := unwrapSubrouter(node).(type) {
+		case *staticNode:
+		case *templateNode:
+			for _, part := range n.parts {
+				if part.isParam() && part.name == key {
+					return true
+				}
+			}
+		default:
+			if node.Name() == key {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// urlPart builds path part of the Node, param values are percent-encoded
+func urlPart(node Node, params context.Params) (string, error) {
+	switch n := node.(type) {
+	case *staticNode:
+		return n.name, nil
+	case *wildcardNode:
+		value := params.Value(n.name)
+		if value == "" {
+			return "", fmt.Errorf("missing value for wildcard {%s}", n.name)
+		}
+
+		return url.PathEscape(value), nil
+	case *constraintNode:
+		value := params.Value(n.name)
+		if value == "" {
+			return "", fmt.Errorf("missing value for wildcard {%s:%s}", n.name, n.constraint)
+		}
+		if !n.match(value) {
+			return "", fmt.Errorf("value %q does not match wildcard {%s:%s}", value, n.name, n.constraint)
+		}
+
+		return url.PathEscape(value), nil
+	case *regexpNode:
+		value := params.Value(n.name)
+		if value == "" {
+			return "", fmt.Errorf("missing value for wildcard {%s:%s}", n.name, n.exp)
+		}
+		if !n.regexp.MatchString(value) {
+			return "", fmt.Errorf("value %q does not match wildcard {%s:%s}", value, n.name, n.exp)
+		}
+
+		return url.PathEscape(value), nil
+	case *catchAllNode:
+		value := params.Value(n.name)
+		if value == "" {
+			return "", fmt.Errorf("missing value for wildcard {%s*}", n.name)
+		}
+
+		// slashes are kept, each of the path parts is encoded separately
+		parts := strings.Split(strings.Trim(value, "/"), "/")
+		for i, part := range parts {
+			parts[i] = url.PathEscape(part)
+		}
+
+		return strings.Join(parts, "/"), nil
+	case *templateNode:
+		var b strings.Builder
+		for _, part := range n.parts {
+			if !part.isParam() {
+				b.WriteString(part.literal)
+				continue
+			}
+
+			value := params.Value(part.name)
+			if value == "" {
+				return "", fmt.Errorf("missing value for wildcard {%s} of %s", part.name, n.name)
+			}
+			if part.match != nil && !part.match(value) {
+				return "", fmt.Errorf("value %q does not match wildcard {%s:%s} of %s", value, part.name, part.exp, n.name)
+			}
+
+			b.WriteString(url.PathEscape(value))
+		}
+
+		return b.String(), nil
+	case *subrouterNode:
+		return urlPart(n.Node, params)
+	}
+
+	return "", fmt.Errorf("unsupported node type %T", node)
+}
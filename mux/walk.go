@@ -0,0 +1,73 @@
+package mux
+
+import "errors"
+
+// Kind describes Node type
+type Kind int
+
+const (
+	// KindStatic is a Node matching path part equal to its name, e.g. /users
+	KindStatic Kind = iota
+	// KindWildcard is a Node matching any path part, e.g. /{name}
+	KindWildcard
+	// KindRegexp is a Node matching path part with regexp, e.g. /{name:[a-z]+}
+	KindRegexp
+	// KindConstraint is a Node matching path part with constraint, e.g. /{id:int}
+	KindConstraint
+	// KindCatchAll is a Node matching the rest of the path, e.g. /{path*}
+	KindCatchAll
+	// KindSubrouter is a Node of mounted handler
+	KindSubrouter
+	// KindTemplate is a Node matching path part combining literals with params, e.g. /{name}.{ext}
+	KindTemplate
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindStatic:
+		return "static"
+	case KindWildcard:
+		return "wildcard"
+	case KindRegexp:
+		return "regexp"
+	case KindConstraint:
+		return "constraint"
+	case KindCatchAll:
+		return "catch-all"
+	case KindSubrouter:
+		return "subrouter"
+	case KindTemplate:
+		return "template"
+	default:
+		return "unknown"
+	}
+}
+
+// SkipChildren is used as a return value from Walk function
+// to skip children of the Node, it is not returned as an error by Walk
+var SkipChildren = errors.New("skip children")
+
+// Walk calls fn for every Node within the Tree in matching order, parents before children,
+// path holds parents of the Node and must not be retained by fn,
+// walking stops on the first error returned by fn
+func Walk(t Tree, fn func(path []Node, n Node) error) error {
+	return walk(t, nil, fn)
+}
+
+func walk(t Tree, path []Node, fn func(path []Node, n Node) error) error {
+	for _, child := range t {
+		if err := fn(path, child); err != nil {
+			if errors.Is(err, SkipChildren) {
+				continue
+			}
+
+			return err
+		}
+
+		if err := walk(child.Tree(), append(path, child), fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
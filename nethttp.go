@@ -1,8 +1,10 @@
 package gorouter
 
 import (
+	"errors"
 	"net/http"
 	"net/url"
+	"sort"
 	"strings"
 
 	"github.com/vardius/gorouter/v4/context"
@@ -25,120 +27,476 @@ var allNethttpMethods = []string{
 
 // New creates new net/http Router instance, returns pointer
 func New(fs ...MiddlewareFunc) Router {
-	globalMiddleware := transformMiddlewareFunc(fs...)
+	return NewWithOptions(WithMiddleware(fs...))
+}
+
+// NewWithOptions creates new net/http Router instance configured with given options, returns pointer
+func NewWithOptions(opts ...Option) Router {
+	c := newNetHTTPConfig(opts...)
+	globalMiddleware := transformMiddlewareFunc(c.middleware...)
 
 	r := &router{
 		tree:             mux.NewTree(),
+		routes:           make(namedRoutes),
 		globalMiddleware: globalMiddleware,
+		headFallback:     c.headFallback,
+		autoOptions:      c.autoOptions,
+		routePattern:     c.routePattern,
+		rawPath:          c.rawPath,
+	}
+	r.live.concurrent = c.concurrent
+	r.live.settings = &routerSettings{
+		notFound:          c.notFound,
+		notAllowed:        c.notAllowed,
+		errorHandler:      c.errorHandler,
+		optionsHandler:    c.optionsHandler,
+		trailingSlash:     c.trailingSlash,
+		redirectCleanPath: c.redirectCleanPath,
 	}
 
 	r.handler = globalMiddleware.Compose(http.HandlerFunc(r.serveHTTP)).(http.Handler)
 
+	if c.panicHandler != nil {
+		r.handler = newPanicRecoverer(r.handler, c.panicHandler)
+	}
+
 	return r
 }
 
 type router struct {
 	tree              mux.Tree
+	routes            namedRoutes
 	globalMiddleware  middleware.Collection
+	handler           http.Handler
+	middlewareCounter uint
+	compiled          bool
+	headFallback      bool
+	autoOptions       bool
+	ignoreCase        bool
+	routePattern      bool
+	rawPath           bool
+	hosts             mux.Tree
+	hostRouters       map[string]*router
+	hostScoped        bool
+	handlers          composedHandlers
+	live              liveRoutes
+}
+
+// routerSettings are router settings which can be changed while serving requests,
+// they are never changed, setters replace them with changed copy
+type routerSettings struct {
 	fileServer        http.Handler
 	notFound          http.Handler
 	notAllowed        http.Handler
-	handler           http.Handler
-	middlewareCounter uint
+	errorHandler      func(http.ResponseWriter, *http.Request, error)
+	optionsHandler    http.Handler
+	trailingSlash     TrailingSlashPolicy
+	redirectCleanPath bool
+	redirectCase      bool
+}
+
+// configure changes copy of router settings
+func (r *router) configure(fn func(s *routerSettings)) {
+	r.live.configure(&r.tree, &r.hosts, func() interface{} {
+		s := *r.live.settings.(*routerSettings)
+		fn(&s)
+
+		return &s
+	})
 }
 
-func (r *router) PrettyPrint() string {
-	return r.tree.PrettyPrint()
+func (r *router) PrettyPrint() (tree string) {
+	r.live.read(func() {
+		tree = r.tree.PrettyPrint()
+	})
+
+	return tree
 }
 
-func (r *router) POST(p string, f http.Handler) {
-	r.Handle(http.MethodPost, p, f)
+func (r *router) POST(p string, f http.Handler, name ...string) {
+	r.Handle(http.MethodPost, p, f, name...)
 }
 
-func (r *router) GET(p string, f http.Handler) {
-	r.Handle(http.MethodGet, p, f)
+func (r *router) GET(p string, f http.Handler, name ...string) {
+	r.Handle(http.MethodGet, p, f, name...)
 }
 
-func (r *router) PUT(p string, f http.Handler) {
-	r.Handle(http.MethodPut, p, f)
+func (r *router) PUT(p string, f http.Handler, name ...string) {
+	r.Handle(http.MethodPut, p, f, name...)
 }
 
-func (r *router) DELETE(p string, f http.Handler) {
-	r.Handle(http.MethodDelete, p, f)
+func (r *router) DELETE(p string, f http.Handler, name ...string) {
+	r.Handle(http.MethodDelete, p, f, name...)
 }
 
-func (r *router) PATCH(p string, f http.Handler) {
-	r.Handle(http.MethodPatch, p, f)
+func (r *router) PATCH(p string, f http.Handler, name ...string) {
+	r.Handle(http.MethodPatch, p, f, name...)
 }
 
-func (r *router) OPTIONS(p string, f http.Handler) {
-	r.Handle(http.MethodOptions, p, f)
+func (r *router) OPTIONS(p string, f http.Handler, name ...string) {
+	r.Handle(http.MethodOptions, p, f, name...)
 }
 
-func (r *router) HEAD(p string, f http.Handler) {
-	r.Handle(http.MethodHead, p, f)
+func (r *router) HEAD(p string, f http.Handler, name ...string) {
+	r.Handle(http.MethodHead, p, f, name...)
 }
 
-func (r *router) CONNECT(p string, f http.Handler) {
-	r.Handle(http.MethodConnect, p, f)
+func (r *router) CONNECT(p string, f http.Handler, name ...string) {
+	r.Handle(http.MethodConnect, p, f, name...)
 }
 
-func (r *router) TRACE(p string, f http.Handler) {
-	r.Handle(http.MethodTrace, p, f)
+func (r *router) TRACE(p string, f http.Handler, name ...string) {
+	r.Handle(http.MethodTrace, p, f, name...)
 }
 
 func (r *router) USE(method, path string, fs ...MiddlewareFunc) {
-	m := transformMiddlewareFunc(fs...)
-	for i, mf := range m {
-		m[i] = middleware.WithPriority(mf, r.middlewareCounter)
-	}
-
-	r.tree = r.tree.WithMiddleware(method+path, m, 0)
-	r.middlewareCounter += uint(len(m))
+	r.useMiddleware([]string{method}, path, fs...)
 }
 
 func (r *router) USEANY(path string, fs ...MiddlewareFunc) {
+	r.useMiddleware(allNethttpMethods, path, fs...)
+}
+
+func (r *router) useMiddleware(methods []string, path string, fs ...MiddlewareFunc) {
+	path = cleanPattern(path)
+
 	m := transformMiddlewareFunc(fs...)
-	for i, mf := range m {
-		m[i] = middleware.WithPriority(mf, r.middlewareCounter)
+
+	r.live.update(&r.tree, &r.hosts, func() {
+		for i, mf := range m {
+			m[i] = middleware.WithPriority(mf, r.middlewareCounter)
+		}
+
+		for _, method := range methods {
+			r.tree = r.tree.WithMiddleware(method+path, m, 0)
+
+			if r.ignoreCase {
+				r.tree.IgnorePathCase(method + path)
+			}
+		}
+
+		r.middlewareCounter += uint(len(m))
+
+		if r.compiled {
+			r.live.compose(r.tree, &r.handlers)
+		}
+	})
+}
+
+func (r *router) Handle(method, path string, h http.Handler, name ...string) {
+	if err := r.TryHandle(method, path, h, name...); err != nil {
+		panic(err)
 	}
+}
 
-	for _, method := range allNethttpMethods {
-		r.tree = r.tree.WithMiddleware(method+path, m, 0)
+func (r *router) TryHandle(method, path string, h http.Handler, name ...string) (err error) {
+	if h == nil {
+		return errors.New("handler can not be nil")
 	}
 
-	r.middlewareCounter += uint(len(m))
+	r.live.update(&r.tree, &r.hosts, func() {
+		err = r.handle(method, path, h, name...)
+	})
+
+	return err
 }
 
-func (r *router) Handle(method, path string, h http.Handler) {
+// handle registers route, router trees have to be updated exclusively
+func (r *router) handle(method, path string, h http.Handler, name ...string) error {
+	path = cleanPattern(path)
+
+	if err := r.routes.check(name); err != nil {
+		return err
+	}
+	if err := checkRoute(r.tree, method, path, false); err != nil {
+		return err
+	}
+
 	route := newRoute(h)
+	route.trailingSlash = hasTrailingSlash(path)
+	route.pattern = context.Route{Method: method, Pattern: path}
 
 	r.tree = r.tree.WithRoute(method+path, route, 0)
+	r.routes.add(method, route, name)
+
+	if r.ignoreCase {
+		r.tree.IgnorePathCase(method + path)
+	}
+
+	return nil
+}
+
+func (r *router) Replace(method, path string, h http.Handler) {
+	path = cleanPattern(path)
+
+	if h == nil {
+		panic("Handler can not be nil.")
+	}
+
+	var err error
+	r.live.update(&r.tree, &r.hosts, func() {
+		if !replaceRoute(r.tree, r.routes, method, path, h) {
+			err = r.handle(method, path, h)
+			return
+		}
+
+		if r.compiled {
+			r.live.compose(r.tree, &r.handlers)
+		}
+	})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (r *router) Remove(method, path string) (removed bool) {
+	path = cleanPattern(path)
+
+	r.live.update(&r.tree, &r.hosts, func() {
+		var rt *route
+		if r.tree, rt = removeRoute(r.tree, method, path); rt == nil {
+			return
+		}
+
+		r.routes.remove(rt)
+		removed = true
+
+		if r.compiled {
+			// removed nodes may leave static nodes which can be compiled together
+			compileTree(r.tree)
+			r.live.compose(r.tree, &r.handlers)
+		}
+	})
+
+	return removed
+}
+
+func (r *router) HandleE(method, path string, h func(http.ResponseWriter, *http.Request) error, name ...string) {
+	if h == nil {
+		panic("Handler can not be nil.")
+	}
+
+	r.Handle(method, path, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		defer func() {
+			if rcv := recover(); rcv != nil {
+				if rcv == http.ErrAbortHandler {
+					panic(rcv)
+				}
+
+				r.serveError(w, req, &PanicError{Value: rcv})
+			}
+		}()
+
+		if err := h(w, req); err != nil {
+			r.serveError(w, req, err)
+		}
+	}), name...)
+}
+
+func (r *router) URL(name string, params ...string) (url string, err error) {
+	r.live.read(func() {
+		url, err = buildURL(r.tree, r.routes, name, params...)
+	})
+
+	return url, err
 }
 
 func (r *router) Mount(path string, h http.Handler) {
+	path = cleanPattern(path)
 	pathRewrite := newPathSlashesStripper(strings.Count(path, "/"))
-	route := newRoute(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		h.ServeHTTP(w, pathRewrite(r))
-	}))
+	})
+
+	var err error
+	r.live.update(&r.tree, &r.hosts, func() {
+		for _, method := range allNethttpMethods {
+			if err = checkRoute(r.tree, method, path, true); err != nil {
+				return
+			}
+		}
+
+		for _, method := range allNethttpMethods {
+			// route per method, each of them has its own middleware
+			route := newRoute(handler)
+			route.subrouter = true
+			route.pattern = context.Route{Method: method, Pattern: path}
+
+			r.tree = r.tree.WithSubrouter(method+path, route, 0)
+
+			if r.ignoreCase {
+				r.tree.IgnorePathCase(method + path)
+			}
+		}
+	})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (r *router) Update(fn func(r Router)) {
+	r.live.batch(&r.tree, &r.hosts, func() {
+		fn(r)
+	})
+}
+
+func (r *router) Group(prefix string, fn func(g RouteGroup)) {
+	fn(&group{router: r, prefix: prefix})
+}
+
+func (r *router) Host(pattern string) Router {
+	var (
+		h   *router
+		err error
+	)
+
+	r.live.update(&r.tree, &r.hosts, func() {
+		h, err = r.host(pattern)
+	})
+
+	if err != nil {
+		panic(err)
+	}
+
+	return h
+}
+
+// host returns router scoped to the host pattern, creating it if needed
+func (r *router) host(pattern string) (*router, error) {
+	if h, ok := r.hostRouters[pattern]; ok {
+		return h, nil
+	}
+	if err := checkHost(r.hosts, pattern); err != nil {
+		return nil, err
+	}
+
+	settings := *r.live.settings.(*routerSettings)
+	settings.fileServer = nil
+
+	h := &router{
+		tree:         mux.NewTree(),
+		routes:       make(namedRoutes),
+		headFallback: r.headFallback,
+		autoOptions:  r.autoOptions,
+		ignoreCase:   r.ignoreCase,
+		routePattern: r.routePattern,
+		rawPath:      r.rawPath,
+		hostScoped:   true,
+	}
+	h.live.concurrent = r.live.concurrent
+	h.live.settings = &settings
+	h.handler = http.HandlerFunc(h.serveHTTP)
+
+	if r.hostRouters == nil {
+		r.hostRouters = make(map[string]*router)
+	}
+
+	r.hostRouters[pattern] = h
+	hostRoute := newRoute(h)
+	hostRoute.pattern = context.Route{Pattern: pattern}
+
+	r.hosts = r.hosts.WithRoute(hostPatternPath(pattern), hostRoute, 0)
 
-	for _, method := range allNethttpMethods {
-		r.tree = r.tree.WithSubrouter(method+path, route, 0)
+	return h, nil
+}
+
+func (r *router) Routes() []RouteInfo {
+	var (
+		routes      []RouteInfo
+		hostRouters map[string]*router
+	)
+
+	r.live.read(func() {
+		routes = routesInfo(r.tree)
+
+		hostRouters = make(map[string]*router, len(r.hostRouters))
+		for pattern, h := range r.hostRouters {
+			hostRouters[pattern] = h
+		}
+	})
+
+	hosts := make([]string, 0, len(hostRouters))
+	for pattern := range hostRouters {
+		hosts = append(hosts, pattern)
 	}
+	sort.Strings(hosts)
+
+	for _, pattern := range hosts {
+		for _, info := range hostRouters[pattern].Routes() {
+			if info.Host == "" {
+				info.Host = pattern
+			}
+
+			routes = append(routes, info)
+		}
+	}
+
+	return routes
 }
 
 func (r *router) Compile() {
-	for i, methodNode := range r.tree {
-		r.tree[i].WithChildren(methodNode.Tree().Compile())
+	var hostRouters []*router
+
+	r.live.update(&r.tree, &r.hosts, func() {
+		compileTree(r.tree)
+		r.live.compose(r.tree, &r.handlers)
+		r.compiled = true
+
+		for _, h := range r.hostRouters {
+			hostRouters = append(hostRouters, h)
+		}
+	})
+
+	for _, h := range hostRouters {
+		h.Compile()
 	}
 }
 
 func (r *router) NotFound(notFound http.Handler) {
-	r.notFound = notFound
+	r.configure(func(s *routerSettings) {
+		s.notFound = notFound
+	})
+}
+
+func (r *router) AutoOptions(h http.Handler) {
+	r.configure(func(s *routerSettings) {
+		s.optionsHandler = h
+	})
 }
 
 func (r *router) NotAllowed(notAllowed http.Handler) {
-	r.notAllowed = notAllowed
+	r.configure(func(s *routerSettings) {
+		s.notAllowed = notAllowed
+	})
+}
+
+func (r *router) OnError(fn func(w http.ResponseWriter, req *http.Request, err error)) {
+	r.configure(func(s *routerSettings) {
+		s.errorHandler = fn
+	})
+}
+
+func (r *router) TrailingSlash(policy TrailingSlashPolicy) {
+	r.configure(func(s *routerSettings) {
+		s.trailingSlash = policy
+	})
+}
+
+func (r *router) RedirectCleanPath(redirect bool) {
+	r.configure(func(s *routerSettings) {
+		s.redirectCleanPath = redirect
+	})
+}
+
+func (r *router) CaseInsensitive(redirect bool) {
+	r.configure(func(s *routerSettings) {
+		s.redirectCase = redirect
+
+		r.ignoreCase = true
+		r.tree.IgnoreCase()
+	})
 }
 
 func (r *router) ServeFiles(fs http.FileSystem, root string, strip bool) {
@@ -149,7 +507,9 @@ func (r *router) ServeFiles(fs http.FileSystem, root string, strip bool) {
 	if strip {
 		handler = http.StripPrefix("/"+root+"/", handler)
 	}
-	r.fileServer = handler
+	r.configure(func(s *routerSettings) {
+		s.fileServer = handler
+	})
 }
 
 func (r *router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
@@ -157,89 +517,194 @@ func (r *router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 }
 
 func (r *router) serveHTTP(w http.ResponseWriter, req *http.Request) {
-	var path string
-
-	if root := r.tree.Find(req.Method); root != nil {
-		var h http.Handler
-
-		if req.URL.Path == "/" {
-			if root.Route() != nil && root.Route().Handler() != nil {
-				if r.middlewareCounter > 0 {
-					computedHandler := root.Middleware().Sort().Compose(root.Route().Handler())
-
-					h = computedHandler.(http.Handler)
-				} else {
-					h = root.Route().Handler().(http.Handler)
-				}
-
-				h.ServeHTTP(w, req)
-				return
+	table := r.live.load(&r.tree, &r.hosts, r.handlers)
+	s := table.settings.(*routerSettings)
+
+	// Handle host routers
+	if len(table.hosts) > 0 {
+		if rt, params := table.hosts.MatchRoute(hostPath(req.Host)); rt != nil {
+			if len(params) > 0 {
+				req = req.WithContext(context.WithParams(req.Context(), params))
 			}
-		} else {
-			path = pathutils.TrimSlash(req.URL.Path)
-
-			if route, params := root.Tree().MatchRoute(path); route != nil {
-				if r.middlewareCounter > 0 {
-					var allMiddleware middleware.Collection
-					if treeMiddleware := root.Tree().MatchMiddleware(path); len(treeMiddleware) > 0 {
-						allMiddleware = root.Middleware().Merge(treeMiddleware).Sort()
-					} else {
-						allMiddleware = root.Middleware().Sort()
-					}
-
-					computedHandler := allMiddleware.Compose(route.Handler())
-
-					h = computedHandler.(http.Handler)
-				} else {
-					h = route.Handler().(http.Handler)
-				}
 
-				if len(params) > 0 {
-					req = req.WithContext(context.WithParams(req.Context(), params))
-				}
+			rt.Handler().(*router).ServeHTTP(w, req)
+			return
+		}
+	}
 
-				h.ServeHTTP(w, req)
-				return
-			}
+	path := req.URL.Path
+	if r.rawPath {
+		path = pathutils.Unescape(req.URL.EscapedPath())
+	}
+
+	// Handle not clean path
+	if s.redirectCleanPath {
+		if cleaned := pathutils.Clean(path); cleaned != path {
+			r.redirect(w, req, cleaned)
+			return
 		}
 	}
 
-	path = pathutils.TrimSlash(req.URL.Path)
+	if r.serveRoute(w, req, table, req.Method, path) {
+		return
+	}
+
+	// Handle HEAD with GET route
+	if req.Method == http.MethodHead && r.headFallback && r.serveRoute(w, req, table, http.MethodGet, path) {
+		return
+	}
 
 	// Handle file serve
-	if req.Method == http.MethodGet && r.fileServer != nil {
-		r.fileServer.ServeHTTP(w, req)
+	if req.Method == http.MethodGet && s.fileServer != nil {
+		s.fileServer.ServeHTTP(w, req)
 		return
 	}
 
 	// Handle OPTIONS
-	if allow := allowed(r.tree, req.Method, path); len(allow) > 0 {
+	if allow := allowed(table.tree, req.Method, path, r.headFallback, s.trailingSlash != TrailingSlashLenient); len(allow) > 0 {
 		w.Header().Set("Allow", allow)
 
-		if req.Method == http.MethodOptions {
+		if req.Method == http.MethodOptions && r.autoOptions {
+			if s.optionsHandler != nil {
+				s.optionsHandler.ServeHTTP(w, req)
+			}
 			return
 		}
 
 		// Handle 405
-		r.serveNotAllowed(w, req)
+		s.serveNotAllowed(w, req)
 		return
 	}
 
 	// Handle 404
-	r.serveNotFound(w, req)
+	s.serveNotFound(w, req)
+}
+
+// serveRoute dispatches the request to the route registered under given method,
+// reports if route was found
+func (r *router) serveRoute(w http.ResponseWriter, req *http.Request, table routeTable, method, path string) bool {
+	s := table.settings.(*routerSettings)
+
+	root := table.tree.Find(method)
+	if root == nil {
+		return false
+	}
+
+	var (
+		rt      mux.Route
+		params  context.Params
+		trimmed string
+	)
+
+	if path == "/" {
+		rt = root.Route()
+	} else {
+		trimmed = pathutils.TrimSlash(path)
+		rt, params = root.Tree().MatchRoute(trimmed)
+	}
+
+	if rt == nil {
+		return false
+	}
+
+	// Handle trailing slash
+	if s.trailingSlash != TrailingSlashLenient && !rt.(*route).matchesSlash(path) {
+		if s.trailingSlash == TrailingSlashStrict {
+			return false
+		}
+
+		r.redirect(w, req, toggleTrailingSlash(path))
+		return true
+	}
+
+	// Handle registered path casing
+	if s.redirectCase && trimmed != "" && !rt.(*route).subrouter {
+		if cased, ok := rt.(*route).casedPath(trimmed, params); ok {
+			if hasTrailingSlash(path) {
+				cased += "/"
+			}
+
+			r.redirect(w, req, "/"+cased)
+			return true
+		}
+	}
+
+	if r.rawPath {
+		unescapeParams(params)
+	}
+
+	h := r.routeHandler(table.handlers, root, rt, trimmed)
+
+	// Handle host params
+	if r.hostScoped {
+		if hostParams, ok := context.Parameters(req.Context()); ok {
+			params = append(hostParams[:len(hostParams):len(hostParams)], params...)
+		}
+	}
+
+	if r.routePattern {
+		if len(params) == 0 {
+			params = nil
+		}
+
+		req = req.WithContext(context.WithRoute(req.Context(), params, &rt.(*route).pattern))
+	} else if len(params) > 0 {
+		req = req.WithContext(context.WithParams(req.Context(), params))
+	}
+
+	h.ServeHTTP(w, req)
+	return true
+}
+
+// redirect replies to the request with redirect to given path keeping the query string
+func (r *router) redirect(w http.ResponseWriter, req *http.Request, path string) {
+	u := *req.URL
+	if r.rawPath {
+		u.RawPath = escapePath(path)
+		u.Path, _ = url.PathUnescape(u.RawPath)
+	} else {
+		u.Path = path
+		u.RawPath = ""
+	}
+
+	http.Redirect(w, req, u.String(), redirectCode(req.Method))
+}
+
+// routeHandler returns route handler wrapped with middleware, path is empty for the root route
+func (r *router) routeHandler(handlers composedHandlers, root mux.Node, rt mux.Route, path string) http.Handler {
+	if h, ok := handlers[rt.(*route)]; ok {
+		return h.(http.Handler)
+	}
+
+	if !r.live.concurrent && r.middlewareCounter == 0 {
+		return rt.Handler().(http.Handler)
+	}
+
+	// merge always allocates new collection, tree owned ones must not be sorted in place
+	allMiddleware := root.Middleware().Merge(matchTreeMiddleware(root, path)).Sort()
+
+	return allMiddleware.Compose(rt.Handler()).(http.Handler)
 }
 
-func (r *router) serveNotFound(w http.ResponseWriter, req *http.Request) {
-	if r.notFound != nil {
-		r.notFound.ServeHTTP(w, req)
+func (r *router) serveError(w http.ResponseWriter, req *http.Request, err error) {
+	if s := r.live.loadSettings().(*routerSettings); s.errorHandler != nil {
+		s.errorHandler(w, req, err)
+	} else {
+		DefaultErrorHandler(w, req, err)
+	}
+}
+
+func (s *routerSettings) serveNotFound(w http.ResponseWriter, req *http.Request) {
+	if s.notFound != nil {
+		s.notFound.ServeHTTP(w, req)
 	} else {
 		http.NotFound(w, req)
 	}
 }
 
-func (r *router) serveNotAllowed(w http.ResponseWriter, req *http.Request) {
-	if r.notAllowed != nil {
-		r.notAllowed.ServeHTTP(w, req)
+func (s *routerSettings) serveNotAllowed(w http.ResponseWriter, req *http.Request) {
+	if s.notAllowed != nil {
+		s.notAllowed.ServeHTTP(w, req)
 	} else {
 		http.Error(w,
 			http.StatusText(http.StatusMethodNotAllowed),
@@ -262,6 +727,24 @@ func transformMiddlewareFunc(fs ...MiddlewareFunc) middleware.Collection {
 	return m
 }
 
+// newPanicRecoverer recovers handler panics replying to the request with panic handler,
+// http.ErrAbortHandler is not recovered as it is used to abort the response
+func newPanicRecoverer(next http.Handler, panicHandler func(http.ResponseWriter, *http.Request, interface{})) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		defer func() {
+			if rcv := recover(); rcv != nil {
+				if rcv == http.ErrAbortHandler {
+					panic(rcv)
+				}
+
+				panicHandler(w, req, rcv)
+			}
+		}()
+
+		next.ServeHTTP(w, req)
+	})
+}
+
 func newPathSlashesStripper(stripSlashes int) func(r *http.Request) *http.Request {
 	return func(r *http.Request) *http.Request {
 		r2 := new(http.Request)
@@ -276,6 +759,11 @@ func newPathSlashesStripper(stripSlashes int) func(r *http.Request) *http.Reques
 			r2.URL.Path = "/"
 		}
 
+		// keep encoded path in sync, used when matching raw path
+		if r.URL.RawPath != "" {
+			r2.URL.RawPath = pathutils.StripLeadingSlashes(r.URL.RawPath, stripSlashes)
+		}
+
 		return r2
 	}
 }
@@ -1,11 +1,14 @@
 package gorouter
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/vardius/gorouter/v4/context"
@@ -15,6 +18,84 @@ func TestInterface(t *testing.T) {
 	var _ http.Handler = New()
 }
 
+func TestNewWithOptions(t *testing.T) {
+	t.Parallel()
+
+	header := func(value string) MiddlewareFunc {
+		return func(h http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Add("X-Middleware", value)
+				h.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	router := NewWithOptions(
+		WithMiddleware(header("m1"), header("m2")),
+		WithNotFound(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		})),
+		WithNotAllowed(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusConflict)
+		})),
+		WithPanicHandler(func(w http.ResponseWriter, _ *http.Request, rcv interface{}) {
+			w.WriteHeader(http.StatusInternalServerError)
+			if _, err := w.Write([]byte(fmt.Sprint(rcv))); err != nil {
+				t.Fatal(err)
+			}
+		}),
+		WithTrailingSlash(TrailingSlashStrict),
+		WithHeadFallback(false),
+		WithAutoOptions(false),
+	).(*router)
+
+	router.GET("/x", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if _, err := w.Write([]byte("x")); err != nil {
+			t.Fatal(err)
+		}
+	}))
+	router.GET("/panic", http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+		panic("oops")
+	}))
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		code   int
+		body   string
+	}{
+		{"middleware", http.MethodGet, "/x", http.StatusOK, "x"},
+		{"not found", http.MethodGet, "/y", http.StatusTeapot, ""},
+		{"not allowed", http.MethodPost, "/x", http.StatusConflict, ""},
+		{"trailing slash", http.MethodGet, "/x/", http.StatusTeapot, ""},
+		{"head fallback", http.MethodHead, "/x", http.StatusConflict, ""},
+		{"auto options", http.MethodOptions, "/x", http.StatusConflict, ""},
+		{"panic", http.MethodGet, "/panic", http.StatusInternalServerError, "oops"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, err := http.NewRequest(tt.method, tt.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.code {
+				t.Errorf("%s %s: status code = %d, want %d", tt.method, tt.path, w.Code, tt.code)
+			}
+			if w.Body.String() != tt.body {
+				t.Errorf("%s %s: body = %q, want %q", tt.method, tt.path, w.Body.String(), tt.body)
+			}
+			if middleware := strings.Join(w.Header().Values("X-Middleware"), ","); middleware != "m1,m2" {
+				t.Errorf("%s %s: middleware = %q, want %q", tt.method, tt.path, middleware, "m1,m2")
+			}
+		})
+	}
+}
+
 func TestHandle(t *testing.T) {
 	t.Parallel()
 
@@ -70,6 +151,113 @@ func TestOPTIONSHeaders(t *testing.T) {
 	}
 }
 
+func TestAutoOptionsHandler(t *testing.T) {
+	t.Parallel()
+
+	var allow string
+	router := NewWithOptions(WithAutoOptionsHandler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		allow = w.Header().Get("Allow")
+		w.WriteHeader(http.StatusNoContent)
+	}))).(*router)
+
+	router.GET("/x", &mockHandler{})
+	router.OPTIONS("/y", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, "/x", nil))
+
+	if w.Code != http.StatusNoContent || allow != "GET, HEAD, OPTIONS" {
+		t.Errorf("expected automatic OPTIONS handler to be called with Allow header, got %d %q", w.Code, allow)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, "/y", nil))
+
+	if w.Code != http.StatusAccepted {
+		t.Errorf("expected OPTIONS route to be served, got %d", w.Code)
+	}
+
+	router.AutoOptions(nil)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, "/x", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected empty reply without automatic OPTIONS handler, got %d", w.Code)
+	}
+}
+
+func TestRawPath(t *testing.T) {
+	t.Parallel()
+
+	var got context.Params
+	handler := http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		got, _ = context.Parameters(r.Context())
+	})
+
+	router := NewWithOptions(WithRawPath(true), WithTrailingSlash(TrailingSlashRedirect)).(*router)
+	router.GET("/files/{id}/meta", handler)
+	router.GET("/café/{name}", handler)
+	router.GET("/all/{path*}", handler)
+
+	subrouter := NewWithOptions(WithRawPath(true))
+	subrouter.GET("/files/{id}", handler)
+	router.Mount("/v1", subrouter)
+
+	tests := []struct {
+		name     string
+		path     string
+		code     int
+		location string
+		params   context.Params
+	}{
+		{"encoded slash", "/files/a%2Fb/meta", http.StatusOK, "", context.Params{{Key: "id", Value: "a/b"}}},
+		{"encoded percent", "/files/100%25/meta", http.StatusOK, "", context.Params{{Key: "id", Value: "100%"}}},
+		{"double encoded slash", "/files/a%252Fb/meta", http.StatusOK, "", context.Params{{Key: "id", Value: "a%2Fb"}}},
+		{"unicode", "/caf%C3%A9/%E2%9C%93", http.StatusOK, "", context.Params{{Key: "name", Value: "✓"}}},
+		{"catch-all", "/all/a%2Fb/c", http.StatusOK, "", context.Params{{Key: "path", Value: "a/b/c"}}},
+		{"mounted", "/v1/files/a%2Fb", http.StatusOK, "", context.Params{{Key: "id", Value: "a/b"}}},
+		{"trailing slash redirect", "/files/a%2Fb/meta/?x=1", http.StatusMovedPermanently, "/files/a%2Fb/meta?x=1", nil},
+		{"not matching decoded slash", "/files/a/b/meta", http.StatusNotFound, "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = nil
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if w.Code != tt.code {
+				t.Errorf("expected code %d, got %d", tt.code, w.Code)
+			}
+			if location := w.Header().Get("Location"); location != tt.location {
+				t.Errorf("expected location %q, got %q", tt.location, location)
+			}
+			if !reflect.DeepEqual(got, tt.params) {
+				t.Errorf("expected params %v, got %v", tt.params, got)
+			}
+		})
+	}
+
+	// decoded path is matched by default, encoded slash splits path parts
+	decoded := New()
+	decoded.GET("/files/{id}/meta", handler)
+	decoded.GET("/files/{id}/{name}/meta", handler)
+
+	got = nil
+	w := httptest.NewRecorder()
+	decoded.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/files/a%2Fb/meta", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected code %d without raw path, got %d", http.StatusOK, w.Code)
+	}
+	if want := (context.Params{{Key: "id", Value: "a"}, {Key: "name", Value: "b"}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected params %v without raw path, got %v", want, got)
+	}
+}
+
 func TestMethods(t *testing.T) {
 	t.Parallel()
 
@@ -84,6 +272,7 @@ func TestMethods(t *testing.T) {
 		http.MethodTrace,
 		http.MethodOptions,
 	} {
+		method := method
 		t.Run(method, func(t *testing.T) {
 			t.Parallel()
 
@@ -132,7 +321,7 @@ func TestNotFound(t *testing.T) {
 		}
 	}))
 
-	if router.notFound == nil {
+	if router.live.settings.(*routerSettings).notFound == nil {
 		t.Error("NotFound handler error")
 	}
 
@@ -170,7 +359,7 @@ func TestNotAllowed(t *testing.T) {
 		}
 	}))
 
-	if router.notAllowed == nil {
+	if router.live.settings.(*routerSettings).notAllowed == nil {
 		t.Error("NotAllowed handler error")
 	}
 
@@ -195,6 +384,383 @@ func TestNotAllowed(t *testing.T) {
 	}
 }
 
+func TestHeadFallback(t *testing.T) {
+	t.Parallel()
+
+	router := New().(*router)
+	router.GET("/x/y", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-Test", "get")
+		if _, err := w.Write([]byte("body")); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	// server discards HEAD response body keeping the length of the GET one
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := http.Head(server.URL + "/x/y")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("HEAD request should be served by GET route, got status %d", resp.StatusCode)
+	}
+	if resp.Header.Get("X-Test") != "get" {
+		t.Error("GET handler wasn't invoked")
+	}
+	if resp.Header.Get("Content-Length") != "4" {
+		t.Errorf("HEAD response should have GET response Content-Length, got %q", resp.Header.Get("Content-Length"))
+	}
+	if len(body) != 0 {
+		t.Errorf("HEAD response should not have body, got %q", body)
+	}
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPost, "/x/y", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router.ServeHTTP(w, req)
+
+	if allow := w.Header().Get("Allow"); allow != "GET, HEAD, OPTIONS" {
+		t.Errorf("Allow header should include HEAD, got %q", allow)
+	}
+
+	router.headFallback = false
+
+	w = httptest.NewRecorder()
+	req, err = http.NewRequest(http.MethodHead, "/x/y", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("HEAD request should not be allowed, got status %d", w.Code)
+	}
+	if allow := w.Header().Get("Allow"); allow != "GET, OPTIONS" {
+		t.Errorf("Allow header should not include HEAD, got %q", allow)
+	}
+}
+
+func TestTrailingSlash(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		policy   TrailingSlashPolicy
+		method   string
+		path     string
+		code     int
+		location string
+	}{
+		{"lenient", TrailingSlashLenient, http.MethodGet, "/users/", http.StatusOK, ""},
+		{"lenient with slash", TrailingSlashLenient, http.MethodGet, "/docs", http.StatusOK, ""},
+		{"strict", TrailingSlashStrict, http.MethodGet, "/users", http.StatusOK, ""},
+		{"strict not found", TrailingSlashStrict, http.MethodGet, "/users/", http.StatusNotFound, ""},
+		{"strict with slash", TrailingSlashStrict, http.MethodGet, "/docs/", http.StatusOK, ""},
+		{"strict with slash not found", TrailingSlashStrict, http.MethodPost, "/docs", http.StatusNotFound, ""},
+		{"strict root", TrailingSlashStrict, http.MethodGet, "/", http.StatusOK, ""},
+		{"strict mount", TrailingSlashStrict, http.MethodGet, "/mount/x/", http.StatusOK, ""},
+		{"redirect", TrailingSlashRedirect, http.MethodGet, "/users/?page=2", http.StatusMovedPermanently, "/users?page=2"},
+		{"redirect with slash", TrailingSlashRedirect, http.MethodGet, "/docs", http.StatusMovedPermanently, "/docs/"},
+		{"redirect post", TrailingSlashRedirect, http.MethodPost, "/docs", http.StatusPermanentRedirect, "/docs/"},
+		{"redirect canonical", TrailingSlashRedirect, http.MethodGet, "/users", http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {})
+
+			router := New().(*router)
+			router.TrailingSlash(tt.policy)
+			router.GET("/", handler)
+			router.GET("/users", handler)
+			router.GET("/docs/", handler)
+			router.POST("/docs/", handler)
+			router.Mount("/mount", handler)
+
+			w := httptest.NewRecorder()
+			req, err := http.NewRequest(tt.method, tt.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.code {
+				t.Errorf("%s %s: status code = %d, want %d", tt.method, tt.path, w.Code, tt.code)
+			}
+			if location := w.Header().Get("Location"); location != tt.location {
+				t.Errorf("%s %s: location = %q, want %q", tt.method, tt.path, location, tt.location)
+			}
+		})
+	}
+}
+
+func TestRedirectCleanPath(t *testing.T) {
+	t.Parallel()
+
+	router := New().(*router)
+	router.GET("/a/c", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/a//b/../c", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Not clean path should not match, got status code %d", w.Code)
+	}
+
+	router.RedirectCleanPath(true)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusMovedPermanently {
+		t.Errorf("Not clean path should be redirected, got status code %d", w.Code)
+	}
+	if location := w.Header().Get("Location"); location != "/a/c" {
+		t.Errorf("Location = %q, want %q", location, "/a/c")
+	}
+}
+
+func TestCaseInsensitive(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		redirect bool
+		path     string
+		code     int
+		body     string
+		location string
+	}{
+		{"registered casing", false, "/users/John", http.StatusOK, "John", ""},
+		{"different casing", false, "/USERS/John", http.StatusOK, "John", ""},
+		{"redirect registered casing", true, "/users/John", http.StatusOK, "John", ""},
+		{"redirect different casing", true, "/Users/John?x=1", http.StatusMovedPermanently, "", "/users/John?x=1"},
+		{"redirect trailing slash", true, "/USERS/John/", http.StatusMovedPermanently, "", "/users/John/"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			router := New().(*router)
+			router.GET("/users/{name}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				params, _ := context.Parameters(r.Context())
+				if _, err := w.Write([]byte(params.Value("name"))); err != nil {
+					t.Fatal(err)
+				}
+			}))
+			router.CaseInsensitive(tt.redirect)
+
+			w := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodGet, tt.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.code {
+				t.Errorf("%s: status code = %d, want %d", tt.path, w.Code, tt.code)
+			}
+			if tt.body != "" && w.Body.String() != tt.body {
+				t.Errorf("%s: body = %q, want %q", tt.path, w.Body.String(), tt.body)
+			}
+			if location := w.Header().Get("Location"); location != tt.location {
+				t.Errorf("%s: location = %q, want %q", tt.path, location, tt.location)
+			}
+		})
+	}
+}
+
+func TestRoutePattern(t *testing.T) {
+	t.Parallel()
+
+	var got context.Route
+
+	router := NewWithOptions(WithRoutePattern(true)).(*router)
+	router.Group("/users", func(g RouteGroup) {
+		g.GET("/{id}", http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			params, _ := context.Parameters(r.Context())
+			if params.Value("id") != "42" {
+				t.Errorf("Wrong params value, got %s", params.Value("id"))
+			}
+		}))
+	})
+	router.USE(http.MethodGet, "/users", func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got, _ = context.RoutePattern(r.Context())
+			h.ServeHTTP(w, r)
+		})
+	})
+	router.GET("/", http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		got, _ = context.RoutePattern(r.Context())
+	}))
+
+	tests := []struct {
+		method string
+		path   string
+		want   context.Route
+	}{
+		{http.MethodGet, "/users/42", context.Route{Method: http.MethodGet, Pattern: "/users/{id}"}},
+		{http.MethodHead, "/users/42", context.Route{Method: http.MethodGet, Pattern: "/users/{id}"}},
+		{http.MethodGet, "/", context.Route{Method: http.MethodGet, Pattern: "/"}},
+	}
+	for _, tt := range tests {
+		got = context.Route{}
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(tt.method, tt.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		router.ServeHTTP(w, req)
+
+		if got != tt.want {
+			t.Errorf("%s %s: route pattern = %v, want %v", tt.method, tt.path, got, tt.want)
+		}
+	}
+
+	withoutPattern := New()
+	withoutPattern.GET("/", http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		if _, ok := context.RoutePattern(r.Context()); ok {
+			t.Error("Route pattern should not be stored without option")
+		}
+	}))
+
+	if err := mockServeHTTP(withoutPattern, http.MethodGet, "/"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHost(t *testing.T) {
+	t.Parallel()
+
+	write := func(body string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			params, _ := context.Parameters(r.Context())
+			if _, err := fmt.Fprintf(w, "%s:%s:%s", body, params.Value("tenant"), params.Value("id")); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	router := New(mockMiddleware("g"))
+	router.GET("/users/{id}", write("default"))
+	router.Host("{tenant}.example.com").GET("/users/{id}", write("tenant"))
+	router.Host("api.example.com").GET("/users/{id}", write("api"))
+	router.Host("{lang:[a-z]{2}}.EXAMPLE.org").GET("/", write("org"))
+	router.Host("[::1]").GET("/", write("ipv6"))
+
+	if router.Host("api.example.com") != router.Host("api.example.com") {
+		t.Error("Host should return the same router for the same pattern")
+	}
+
+	router.Compile()
+
+	tests := []struct {
+		host string
+		path string
+		body string
+	}{
+		{"acme.example.com", "/users/1", "gtenant:acme:1"},
+		{"ACME.example.com:8080", "/users/1", "gtenant:acme:1"},
+		{"api.example.com", "/users/1", "gapi::1"},
+		{"example.com", "/users/1", "gdefault::1"},
+		{"a.b.example.com", "/users/1", "gdefault::1"},
+		{"pl.example.org", "/", "gorg::"},
+		{"[::1]:8080", "/", "gipv6::"},
+		{"pol.example.org", "/", "g404 page not found\n"},
+		{"acme.example.com", "/posts", "g404 page not found\n"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, tt.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Host = tt.host
+
+		router.ServeHTTP(w, req)
+
+		if w.Body.String() != tt.body {
+			t.Errorf("%s%s: body = %q, want %q", tt.host, tt.path, w.Body.String(), tt.body)
+		}
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	t.Parallel()
+
+	handler := &mockHandler{}
+	router := New(mockMiddleware("global"))
+	router.GET("/", handler, "home")
+	router.Group("/users", func(g RouteGroup) {
+		g.USE(http.MethodGet, "/", mockMiddleware("users"))
+		g.GET("/{id:int}", handler, "user")
+		g.POST("/{id}/posts/", handler)
+	})
+	router.Mount("/files", handler)
+	router.GET("/reports/{year?:[0-9]+}/{month?}", handler)
+	router.PUT("/{page?}", handler)
+	router.Host("{tenant}.example.com").GET("/{slug:[a-z]+}", handler)
+
+	routes := router.Routes()
+
+	want := []RouteInfo{
+		{Method: http.MethodGet, Pattern: "/", Name: "home"},
+		{Method: http.MethodGet, Pattern: "/users/{id:int}", Name: "user", Params: []string{"id"}, Constraints: map[string]string{"id": "int"}, Middleware: 1},
+		{Method: http.MethodPost, Pattern: "/users/{id}/posts/", Params: []string{"id"}},
+		{Method: http.MethodGet, Pattern: "/reports/{year?:[0-9]+}/{month?}", Params: []string{"year", "month"}, Constraints: map[string]string{"year": "[0-9]+"}},
+		{Method: http.MethodPut, Pattern: "/{page?}", Params: []string{"page"}},
+	}
+	for _, method := range allNethttpMethods {
+		want = append(want, RouteInfo{Method: method, Pattern: "/files", Subrouter: true})
+	}
+	want = append(want, RouteInfo{Method: http.MethodGet, Host: "{tenant}.example.com", Pattern: "/{slug:[a-z]+}", Params: []string{"slug"}, Constraints: map[string]string{"slug": "[a-z]+"}})
+
+	if len(routes) != len(want) {
+		t.Fatalf("expected %d routes, got %d: %+v", len(want), len(routes), routes)
+	}
+
+	for _, info := range want {
+		found := false
+		for _, got := range routes {
+			if reflect.DeepEqual(got, info) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("route %+v not found in %+v", info, routes)
+		}
+	}
+
+	if last := routes[len(routes)-1]; last.Host == "" {
+		t.Errorf("host routes should be listed last, got %+v", last)
+	}
+}
+
 func TestParam(t *testing.T) {
 	t.Parallel()
 
@@ -224,6 +790,41 @@ func TestParam(t *testing.T) {
 	}
 }
 
+func TestConstraintParam(t *testing.T) {
+	t.Parallel()
+
+	router := New().(*router)
+	router.GET("/x/{id:int}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params, _ := context.Parameters(r.Context())
+		if _, err := w.Write([]byte("int:" + params.Value("id"))); err != nil {
+			t.Fatal(err)
+		}
+	}))
+	router.GET("/x/{slug}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params, _ := context.Parameters(r.Context())
+		if _, err := w.Write([]byte("slug:" + params.Value("slug"))); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	for path, want := range map[string]string{
+		"/x/42":    "int:42",
+		"/x/hello": "slug:hello",
+	} {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		router.ServeHTTP(w, req)
+
+		if w.Body.String() != want {
+			t.Errorf("%s: body = %q, want %q", path, w.Body.String(), want)
+		}
+	}
+}
+
 func TestRegexpParam(t *testing.T) {
 	t.Parallel()
 
@@ -281,7 +882,7 @@ func TestServeFiles(t *testing.T) {
 
 	router.ServeFiles(mfs, "static", true)
 
-	if router.fileServer == nil {
+	if router.live.settings.(*routerSettings).fileServer == nil {
 		t.Error("File serve handler error")
 	}
 
@@ -780,3 +1381,1202 @@ func TestMountSubRouter_third(t *testing.T) {
 		t.Errorf("subrouter route did not match: %s", w.Body.String())
 	}
 }
+
+func TestURL(t *testing.T) {
+	t.Parallel()
+
+	handler := &mockHandler{}
+	router := New().(*router)
+
+	router.GET("/", handler, "home")
+	router.GET("/users/{id:[0-9]+}", handler, "user")
+	router.POST("/users/{id}/posts/{slug}", handler, "user_post")
+	router.GET("/x/y", handler)
+	router.GET("/docs/", handler, "docs")
+
+	tests := []struct {
+		name    string
+		route   string
+		params  []string
+		want    string
+		wantErr bool
+	}{
+		{"root", "home", nil, "/", false},
+		{"trailing slash", "docs", nil, "/docs/", false},
+		{"regexp", "user", []string{"id", "42"}, "/users/42", false},
+		{"wildcards", "user_post", []string{"id", "1", "slug", "hello"}, "/users/1/posts/hello", false},
+		{"escaped value", "user_post", []string{"id", "a/b?c#d", "slug", "hello world"}, "/users/a%2Fb%3Fc%23d/posts/hello%20world", false},
+		{"invalid regexp value", "user", []string{"id", "abc"}, "", true},
+		{"missing wildcard", "user_post", []string{"id", "1"}, "", true},
+		{"unknown param", "user", []string{"id", "42", "page", "2"}, "", true},
+		{"root unknown param", "home", []string{"page", "2"}, "", true},
+		{"odd params", "user", []string{"id"}, "", true},
+		{"unknown route", "unknown", nil, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := router.URL(tt.route, tt.params...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("URL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("URL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestURLCompiled(t *testing.T) {
+	t.Parallel()
+
+	handler := &mockHandler{}
+	router := New().(*router)
+
+	router.GET("/x/y/z/{id}", handler, "xyz")
+	router.Compile()
+
+	got, err := router.URL("xyz", "id", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got != "/x/y/z/1" {
+		t.Errorf("URL() = %v, want %v", got, "/x/y/z/1")
+	}
+}
+
+func TestDuplicatedRouteName(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if rcv := recover(); rcv == nil {
+			t.Error("Router should panic for duplicated route name")
+		}
+	}()
+
+	handler := &mockHandler{}
+	router := New().(*router)
+
+	router.GET("/x", handler, "x")
+	router.GET("/y", handler, "x")
+}
+
+func TestTryHandle(t *testing.T) {
+	t.Parallel()
+
+	handler := &mockHandler{}
+	router := New()
+
+	router.GET("/users/{id}", handler, "user")
+	router.GET("/posts/{slug:[a-z]+}", handler)
+	router.Mount("/files", handler)
+
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		routeName []string
+		existing  string
+	}{
+		{"new route", http.MethodGet, "/users/{id}/posts", nil, ""},
+		{"other method", http.MethodPost, "/users/{id}", nil, ""},
+		{"param referenced by name", http.MethodGet, "/posts/{slug}/comments", nil, ""},
+		{"duplicate route", http.MethodGet, "/users/{id}", nil, "GET /users/{id}"},
+		{"duplicate route with trailing slash", http.MethodGet, "/users/{id}/", nil, "GET /users/{id}"},
+		{"ambiguous wildcard", http.MethodGet, "/users/{name}", nil, "GET /users/{id}"},
+		{"different regexp", http.MethodGet, "/posts/{slug:[0-9]+}", nil, "GET /posts/{slug:[a-z]+}"},
+		{"shadowed by mounted handler", http.MethodGet, "/files/{name}", nil, "GET /files"},
+		{"duplicate name", http.MethodGet, "/other", []string{"user"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := router.TryHandle(tt.method, tt.path, handler, tt.routeName...)
+
+			if tt.existing == "" && tt.routeName == nil {
+				if err != nil {
+					t.Errorf("unexpected error %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if tt.existing == "" {
+				return
+			}
+
+			var conflict *ConflictError
+			if !errors.As(err, &conflict) {
+				t.Fatalf("expected conflict error, got %v", err)
+			}
+			if existing := conflict.ExistingMethod + " " + conflict.ExistingPattern; existing != tt.existing {
+				t.Errorf("expected conflict with %s, got %s", tt.existing, existing)
+			}
+			if !strings.Contains(err.Error(), tt.path) || !strings.Contains(err.Error(), conflict.ExistingPattern) {
+				t.Errorf("error %q should name both patterns", err)
+			}
+		})
+	}
+}
+
+func TestTryHandleMalformedPattern(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		path string
+	}{
+		{"catch-all followed by path part", "/c/{p*}/x"},
+		{"invalid regexp", "/{id:[}"},
+		{"template with adjacent params", "/{a}{b}"},
+		{"required part after optional one", "/{a?}/{b}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := New()
+
+			var err error
+			func() {
+				defer func() {
+					if rcv := recover(); rcv != nil {
+						t.Fatalf("TryHandle should not panic, got %v", rcv)
+					}
+				}()
+
+				err = router.TryHandle(http.MethodGet, tt.path, &mockHandler{})
+			}()
+
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if routes := router.Routes(); len(routes) != 0 {
+				t.Errorf("Malformed route should not be registered, got %v", routes)
+			}
+		})
+	}
+}
+
+func TestTryHandleCompiled(t *testing.T) {
+	t.Parallel()
+
+	router := New()
+	router.GET("/a/b/c", &mockHandler{})
+	router.Compile()
+
+	var conflict *ConflictError
+	if err := router.TryHandle(http.MethodGet, "/a/b/c", &mockHandler{}); !errors.As(err, &conflict) {
+		t.Fatalf("expected conflict error, got %v", err)
+	}
+	if conflict.ExistingPattern != "/a/b/c" {
+		t.Errorf("expected conflict with /a/b/c, got %s", conflict.ExistingPattern)
+	}
+	if err := router.TryHandle(http.MethodGet, "/a/b/c/d", &mockHandler{}); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if routes := router.Routes(); len(routes) != 2 {
+		t.Errorf("Routes should list every route once, got %v", routes)
+	}
+}
+
+func TestRouteConflictPanics(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		register func(r Router)
+	}{
+		{"duplicate route", func(r Router) {
+			r.GET("/x", &mockHandler{})
+			r.GET("/x", &mockHandler{})
+		}},
+		{"mount shadows route", func(r Router) {
+			r.GET("/x/y", &mockHandler{})
+			r.Mount("/x", &mockHandler{})
+		}},
+		{"ambiguous host", func(r Router) {
+			r.Host("{a}.example.com").GET("/", &mockHandler{})
+			r.Host("{b}.example.com")
+		}},
+		{"host with port", func(r Router) {
+			r.Host("api.example.com:8080")
+		}},
+		{"group duplicate route", func(r Router) {
+			r.GET("/v1/x", &mockHandler{})
+			r.Group("/v1", func(g RouteGroup) {
+				g.GET("/x", &mockHandler{})
+			})
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if rcv := recover(); rcv == nil {
+					t.Error("Router should panic for conflicting registration")
+				}
+			}()
+
+			tt.register(New())
+		})
+	}
+}
+
+func TestHandleE(t *testing.T) {
+	t.Parallel()
+
+	router := New().(*router)
+
+	router.HandleE(http.MethodGet, "/ok", func(w http.ResponseWriter, _ *http.Request) error {
+		_, err := w.Write([]byte("ok"))
+		return err
+	})
+	router.HandleE(http.MethodGet, "/teapot", func(_ http.ResponseWriter, _ *http.Request) error {
+		return fmt.Errorf("wrapped: %w", mockHTTPError{http.StatusTeapot})
+	})
+	router.HandleE(http.MethodGet, "/users/{id}", func(_ http.ResponseWriter, r *http.Request) error {
+		params, _ := context.Parameters(r.Context())
+		_, err := params.Int("id")
+		return err
+	})
+	router.HandleE(http.MethodGet, "/internal", func(_ http.ResponseWriter, _ *http.Request) error {
+		return errors.New("secret")
+	})
+	router.HandleE(http.MethodGet, "/panic", func(_ http.ResponseWriter, _ *http.Request) error {
+		panic("boom")
+	})
+
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/ok", http.StatusOK, "ok"},
+		{"/teapot", http.StatusTeapot, "mock error 418\n"},
+		{"/users/x", http.StatusBadRequest, "param \"id\": invalid int value \"x\": invalid syntax\n"},
+		{"/internal", http.StatusInternalServerError, "Internal Server Error\n"},
+		{"/panic", http.StatusInternalServerError, "Internal Server Error\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.code {
+				t.Errorf("expected code %d, got %d", tt.code, w.Code)
+			}
+			if w.Body.String() != tt.body {
+				t.Errorf("expected body %q, got %q", tt.body, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestOnError(t *testing.T) {
+	t.Parallel()
+
+	var handled error
+	router := NewWithOptions(WithErrorHandler(func(w http.ResponseWriter, _ *http.Request, _ error) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})).(*router)
+
+	router.Group("/v1", func(g RouteGroup) {
+		g.HandleE(http.MethodGet, "/panic", func(_ http.ResponseWriter, _ *http.Request) error {
+			panic("boom")
+		})
+	})
+
+	router.OnError(func(w http.ResponseWriter, _ *http.Request, err error) {
+		handled = err
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/panic", nil))
+
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("expected code %d, got %d", http.StatusBadGateway, w.Code)
+	}
+
+	var panicErr *PanicError
+	if !errors.As(handled, &panicErr) || panicErr.Value != "boom" {
+		t.Errorf("expected recovered panic error, got %v", handled)
+	}
+
+	// error handler covers HandleE handlers only
+	handled = nil
+	router.GET("/users/{id:[0-9]+}", &mockHandler{})
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/x", nil))
+
+	if w.Code != http.StatusNotFound || handled != nil {
+		t.Errorf("expected not matching param to be replied with the 404 Error code, got %d and %v", w.Code, handled)
+	}
+}
+
+func TestGroup(t *testing.T) {
+	t.Parallel()
+
+	router := New().(*router)
+
+	router.GET("/x", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, "x")
+	}))
+
+	router.Group("/api", func(api RouteGroup) {
+		api.USEANY("/", mockMiddleware("[api]"))
+
+		api.Group("/v1/", func(v1 RouteGroup) {
+			v1.USE(http.MethodGet, "/users", mockMiddleware("[v1]"))
+
+			v1.GET("/users/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				params, _ := context.Parameters(r.Context())
+				_, _ = fmt.Fprintf(w, "user %s", params.Value("id"))
+			}), "user")
+		})
+
+		api.POST("/status", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = fmt.Fprint(w, "status")
+		}))
+	})
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{http.MethodGet, "/x", "x"},
+		{http.MethodGet, "/api/v1/users/1", "[api][v1]user 1"},
+		{http.MethodPost, "/api/status", "[api]status"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(tt.method, tt.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		router.ServeHTTP(w, req)
+
+		if w.Body.String() != tt.body {
+			t.Errorf("%s %s: expected %s, got %s", tt.method, tt.path, tt.body, w.Body.String())
+		}
+	}
+
+	if url, err := router.URL("user", "id", "2"); err != nil || url != "/api/v1/users/2" {
+		t.Errorf("URL() = %s, %v", url, err)
+	}
+}
+
+func TestCatchAllParam(t *testing.T) {
+	t.Parallel()
+
+	router := New().(*router)
+
+	router.GET("/files/{path*}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params, ok := context.Parameters(r.Context())
+		if !ok {
+			t.Fatal("Error while reading param")
+		}
+
+		_, _ = fmt.Fprint(w, params.Value("path"))
+	}), "files")
+	router.GET("/files/{name}/info", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, "info")
+	}))
+
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/files/a.txt", http.StatusOK, "a.txt"},
+		{"/files/a/b/c.txt", http.StatusOK, "a/b/c.txt"},
+		{"/files/a/info", http.StatusOK, "info"},
+		{"/files", http.StatusNotFound, "404 page not found\n"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, tt.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		router.ServeHTTP(w, req)
+
+		if w.Code != tt.code || w.Body.String() != tt.body {
+			t.Errorf("%s: expected %d %q, got %d %q", tt.path, tt.code, tt.body, w.Code, w.Body.String())
+		}
+	}
+
+	if url, err := router.URL("files", "path", "a/b/c.txt"); err != nil || url != "/files/a/b/c.txt" {
+		t.Errorf("URL() = %s, %v", url, err)
+	}
+
+	if !strings.Contains(router.PrettyPrint(), "{path*}") {
+		t.Errorf("PrettyPrint() should contain catch-all node: %s", router.PrettyPrint())
+	}
+}
+
+func TestRegexpParamFullSegmentMatch(t *testing.T) {
+	t.Parallel()
+
+	router := New().(*router)
+
+	router.GET("/x/{id:[0-9]+}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params, _ := context.Parameters(r.Context())
+		_, _ = fmt.Fprint(w, params.Value("id"))
+	}))
+	router.GET("/y/{id:~[0-9]+}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params, _ := context.Parameters(r.Context())
+		_, _ = fmt.Fprint(w, params.Value("id"))
+	}))
+
+	tests := []struct {
+		name string
+		path string
+		code int
+	}{
+		{"anchored match", "/x/123", http.StatusOK},
+		{"anchored no match", "/x/abc1def", http.StatusNotFound},
+		{"anchored prefix no match", "/x/1abc", http.StatusNotFound},
+		{"partial match", "/y/abc1def", http.StatusOK},
+		{"partial no match", "/y/abc", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodGet, tt.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.code {
+				t.Errorf("%s: expected %d, got %d", tt.path, tt.code, w.Code)
+			}
+		})
+	}
+}
+
+func TestCompiledMiddleware(t *testing.T) {
+	t.Parallel()
+
+	handler := func(body string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = fmt.Fprint(w, body)
+		})
+	}
+
+	setup := func() *router {
+		r := New(mockMiddleware("[g]")).(*router)
+
+		r.GET("/", handler("root"))
+		r.GET("/x/{param}", handler("param"))
+		r.GET("/x/y/z", handler("xyz"))
+		r.GET("/a/b", handler("ab"))
+		r.POST("/a/b", handler("post ab"))
+
+		r.USE(http.MethodGet, "/", mockMiddleware("[m]"))
+		r.USE(http.MethodGet, "/x/{param}", mockMiddleware("[param]"))
+		r.USE(http.MethodGet, "/x/x", mockMiddleware("[orphan]"))
+		r.USE(http.MethodGet, "/a", mockMiddleware("[a]"))
+		r.USEANY("/a/b", mockMiddleware("[ab]"))
+
+		return r
+	}
+
+	compiled := setup()
+	compiled.Compile()
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{http.MethodGet, "/", "[g][m]root"},
+		{http.MethodGet, "/x/y", "[g][m][param]param"},
+		{http.MethodGet, "/x/x", "[g][m][param][orphan]param"},
+		{http.MethodGet, "/x/y/z", "[g][m][param]xyz"},
+		{http.MethodGet, "/a/b", "[g][m][a][ab]ab"},
+		{http.MethodPost, "/a/b", "[g][ab]post ab"},
+	}
+	for _, tt := range tests {
+		for name, r := range map[string]*router{"raw": setup(), "compiled": compiled} {
+			w := httptest.NewRecorder()
+			req, err := http.NewRequest(tt.method, tt.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r.ServeHTTP(w, req)
+
+			if w.Body.String() != tt.body {
+				t.Errorf("%s %s %s: expected %s, got %s", name, tt.method, tt.path, tt.body, w.Body.String())
+			}
+		}
+	}
+
+	// middleware added after compilation has to be applied as well
+	compiled.USE(http.MethodGet, "/a/b", mockMiddleware("[late]"))
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/a/b", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	compiled.ServeHTTP(w, req)
+
+	if w.Body.String() != "[g][m][a][ab][late]ab" {
+		t.Errorf("Compiled middleware error: %s", w.Body.String())
+	}
+}
+
+func TestCompiledMiddlewareAllocs(t *testing.T) {
+	noop := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	router := New(noop)
+	router.GET("/x/y/z", http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {}))
+	router.USE(http.MethodGet, "/", noop)
+	router.USE(http.MethodGet, "/x", noop)
+	router.USE(http.MethodGet, "/x/y/z", noop)
+	router.Compile()
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/x/y/z", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if allocs := testing.AllocsPerRun(100, func() { router.ServeHTTP(w, req) }); allocs != 0 {
+		t.Errorf("Compiled middleware routing should not allocate, got %v allocs", allocs)
+	}
+}
+
+func TestConcurrentMiddleware(t *testing.T) {
+	t.Parallel()
+
+	t.Run("raw", func(t *testing.T) {
+		t.Parallel()
+
+		testConcurrentMiddleware(t, false)
+	})
+	t.Run("compiled", func(t *testing.T) {
+		t.Parallel()
+
+		testConcurrentMiddleware(t, true)
+	})
+}
+
+func testConcurrentMiddleware(t *testing.T, compile bool) {
+	router := New(mockMiddleware("[g]")).(*router)
+
+	router.GET("/x/{param}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params, _ := context.Parameters(r.Context())
+		_, _ = fmt.Fprint(w, params.Value("param"))
+	}))
+	router.GET("/x/y/z", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, "xyz")
+	}))
+	router.POST("/x/y/z", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, "post")
+	}))
+
+	// multiple calls grow node collections leaving spare capacity behind
+	for _, m := range []string{"[m1]", "[m2]", "[m3]"} {
+		router.USE(http.MethodGet, "/", mockMiddleware(m))
+		router.USEANY("/x", mockMiddleware(m))
+		router.USE(http.MethodGet, "/x/{param}", mockMiddleware(m))
+	}
+	router.USE(http.MethodGet, "/x/x", mockMiddleware("[orphan]"))
+	router.USEANY("/x/y", mockMiddleware("[xy]"))
+
+	if compile {
+		router.Compile()
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{http.MethodGet, "/x/a", "[g][m1][m1][m1][m2][m2][m2][m3][m3][m3]a"},
+		{http.MethodGet, "/x/x", "[g][m1][m1][m1][m2][m2][m2][m3][m3][m3][orphan]x"},
+		{http.MethodGet, "/x/y/z", "[g][m1][m1][m1][m2][m2][m2][m3][m3][m3][xy]xyz"},
+		{http.MethodPost, "/x/y/z", "[g][m1][m2][m3][xy]post"},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			for j := 0; j < 20; j++ {
+				tt := tests[(i+j)%len(tests)]
+
+				w := httptest.NewRecorder()
+				req, err := http.NewRequest(tt.method, tt.path, nil)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+
+				router.ServeHTTP(w, req)
+
+				if w.Body.String() != tt.body {
+					t.Errorf("%s %s: expected %s, got %s", tt.method, tt.path, tt.body, w.Body.String())
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestRemove(t *testing.T) {
+	t.Parallel()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, r.URL.Path)
+	})
+
+	router := New()
+	router.GET("/", handler)
+	router.GET("/users/{id}", handler, "user")
+	router.GET("/users/{id}/posts", handler)
+	router.GET("/a/c", handler)
+	router.GET("b//d", handler)
+	router.Mount("/files", handler)
+
+	var api RouteGroup
+	router.Group("/api", func(g RouteGroup) {
+		api = g
+		g.GET("/x", handler)
+	})
+
+	tests := []struct {
+		name   string
+		router interface {
+			Remove(method, pattern string) bool
+		}
+		method  string
+		pattern string
+		removed bool
+		path    string
+		code    int
+	}{
+		{"different pattern", router, http.MethodGet, "/users/{id:[0-9]+}", false, "/users/1", http.StatusOK},
+		{"other method", router, http.MethodPost, "/users/{id}", false, "/users/1", http.StatusOK},
+		{"route", router, http.MethodGet, "/users/{id}", true, "/users/1", http.StatusNotFound},
+		{"removed route", router, http.MethodGet, "/users/{id}", false, "/users/1", http.StatusNotFound},
+		{"pattern without leading slash", router, http.MethodGet, "a/c", true, "/a/c", http.StatusNotFound},
+		{"other trailing slash", router, http.MethodGet, "/b/d/", false, "/b/d", http.StatusOK},
+		{"not clean pattern", router, http.MethodGet, "/b/./d", true, "/b/d", http.StatusNotFound},
+		{"root route", router, http.MethodGet, "/", true, "/", http.StatusNotFound},
+		{"mounted handler method", router, http.MethodGet, "/files", true, "/files/a", http.StatusMethodNotAllowed},
+		{"group route", api, http.MethodGet, "/x", true, "/api/x", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if removed := tt.router.Remove(tt.method, tt.pattern); removed != tt.removed {
+				t.Errorf("expected removed %v, got %v", tt.removed, removed)
+			}
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.code {
+				t.Errorf("%s: expected status %d, got %d", tt.path, tt.code, w.Code)
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/1/posts", nil))
+	if w.Body.String() != "/users/1/posts" {
+		t.Errorf("expected nested route to be kept, got %q", w.Body.String())
+	}
+
+	if _, err := router.URL("user", "id", "1"); err == nil {
+		t.Error("expected removed route name to be unregistered")
+	}
+
+	if err := router.TryHandle(http.MethodGet, "/users/{id}", handler, "user"); err != nil {
+		t.Errorf("expected route to be registered again, got %v", err)
+	}
+}
+
+func TestConcurrentUpdates(t *testing.T) {
+	t.Parallel()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, r.URL.Path)
+	})
+
+	router := NewWithOptions(WithConcurrentUpdates(true))
+	router.USE(http.MethodGet, "/stable", mockMiddleware("[m]"))
+	router.GET("/stable", handler)
+	router.HandleE(http.MethodGet, "/teapot", func(_ http.ResponseWriter, _ *http.Request) error {
+		return errors.New("error")
+	})
+	router.Compile()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 100; i++ {
+			pattern := fmt.Sprintf("/flags/%d", i%5)
+
+			router.CaseInsensitive(i%2 == 0)
+			router.TrailingSlash(TrailingSlashPolicy(i % 3))
+			router.RedirectCleanPath(i%2 == 0)
+			router.NotFound(handler)
+			router.NotAllowed(handler)
+			router.OnError(func(w http.ResponseWriter, _ *http.Request, _ error) {
+				w.WriteHeader(http.StatusTeapot)
+			})
+
+			router.Host(fmt.Sprintf("example%d.com", i)).GET(pattern, handler)
+			router.GET(pattern, handler, "flag")
+			router.USE(http.MethodGet, pattern, mockMiddleware("[f]"))
+			if !router.Remove(http.MethodGet, pattern) {
+				t.Errorf("expected %s to be removed", pattern)
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			for j := 0; j < 200; j++ {
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stable", nil))
+				if w.Body.String() != "[m]/stable" {
+					t.Errorf("expected [m]/stable, got %s", w.Body.String())
+				}
+
+				path := fmt.Sprintf("/flags/%d", (i+j)%5)
+				w = httptest.NewRecorder()
+				router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+				if w.Code != http.StatusOK && w.Code != http.StatusNotFound {
+					t.Errorf("%s: unexpected status %d", path, w.Code)
+				}
+
+				w = httptest.NewRecorder()
+				router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/teapot", nil))
+				if w.Code != http.StatusInternalServerError && w.Code != http.StatusTeapot {
+					t.Errorf("/teapot: unexpected status %d", w.Code)
+				}
+
+				_, _ = router.URL("flag")
+				_ = router.Routes()
+			}
+		}(i)
+	}
+	wg.Wait()
+	<-done
+
+	if routes := router.Routes(); len(routes) != 102 || routes[0].Pattern != "/stable" {
+		t.Errorf("expected stable route and host routes to be left, got %+v", routes)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, r.URL.Path)
+	})
+
+	router := NewWithOptions(WithConcurrentUpdates(true))
+	router.USE(http.MethodGet, "/", mockMiddleware("[a]"))
+	router.GET("/x", handler)
+
+	tests := []struct {
+		path   string
+		before string
+		after  string
+	}{
+		{"/x", "[a]/x", "[a][b]/x"},
+		{"/y", "404 page not found\n", "[a][b]/y"},
+		{"/api/z", "404 page not found\n", "[a][b]/api/z"},
+	}
+
+	serve := func(path string) string {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+		return w.Body.String()
+	}
+
+	router.Update(func(r Router) {
+		r.USE(http.MethodGet, "/", mockMiddleware("[b]"))
+		r.GET("/y", handler)
+		r.Group("/api", func(g RouteGroup) {
+			g.Update(func(g RouteGroup) {
+				g.GET("/z", handler)
+			})
+		})
+
+		for _, tt := range tests {
+			if body := serve(tt.path); body != tt.before {
+				t.Errorf("%s: expected routes from before update %q, got %q", tt.path, tt.before, body)
+			}
+		}
+	})
+
+	for _, tt := range tests {
+		if body := serve(tt.path); body != tt.after {
+			t.Errorf("%s: expected updated routes %q, got %q", tt.path, tt.after, body)
+		}
+	}
+}
+
+func TestConcurrentUpdatesKeepPublishedHandlers(t *testing.T) {
+	t.Parallel()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, r.URL.Path)
+	})
+
+	router := NewWithOptions(WithConcurrentUpdates(true)).(*router)
+	router.USE(http.MethodGet, "/", mockMiddleware("[a]"))
+	router.GET("/x", handler)
+
+	table := router.live.load(&router.tree, &router.hosts, router.handlers)
+	tree, handlers := table.tree, table.handlers
+	router.USE(http.MethodGet, "/", mockMiddleware("[b]"))
+
+	// requests in flight keep being served with handlers of the trees they loaded
+	rt, _ := tree.Find(http.MethodGet).Tree().MatchRoute("x")
+	w := httptest.NewRecorder()
+	handlers[rt.(*route)].(http.Handler).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/x", nil))
+
+	if w.Body.String() != "[a]/x" {
+		t.Errorf("expected handler of published trees to be kept, got %s", w.Body.String())
+	}
+}
+
+func TestReplace(t *testing.T) {
+	t.Parallel()
+
+	body := func(s string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = fmt.Fprint(w, s)
+		})
+	}
+
+	router := New()
+	router.USE(http.MethodGet, "/users", mockMiddleware("[m]"))
+	router.GET("/users/{id}", body("a"), "user")
+	router.GET("/posts", body("a"))
+	router.Mount("/files", body("files"))
+	router.Compile()
+
+	router.Replace(http.MethodGet, "/users/{id}", body("b"))
+	router.Replace(http.MethodGet, "/new", body("new"))
+	router.Replace(http.MethodGet, "posts", body("c"))
+
+	tests := []struct {
+		path string
+		body string
+	}{
+		{"/users/1", "[m]b"},
+		{"/new", "new"},
+		{"/posts", "c"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if w.Body.String() != tt.body {
+				t.Errorf("expected %s, got %s", tt.body, w.Body.String())
+			}
+		})
+	}
+
+	if url, err := router.URL("user", "id", "1"); err != nil || url != "/users/1" {
+		t.Errorf("expected replaced route to keep its name, got %q %v", url, err)
+	}
+
+	for _, pattern := range []string{"/files", "/users/{name}"} {
+		t.Run(pattern, func(t *testing.T) {
+			defer func() {
+				if rcv := recover(); rcv == nil {
+					t.Error("expected conflict panic")
+				}
+			}()
+
+			router.Replace(http.MethodGet, pattern, body("c"))
+		})
+	}
+}
+
+func TestRemoveCompiled(t *testing.T) {
+	t.Parallel()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, r.URL.Path)
+	})
+
+	router := New()
+	router.USE(http.MethodGet, "/", mockMiddleware("[g]"))
+	router.USE(http.MethodGet, "/admin", mockMiddleware("[a]"))
+	router.GET("/admin/x", handler)
+	router.GET("/x/y/z", handler)
+	router.Compile()
+	router.GET("/x/y/w", handler)
+
+	router.Remove(http.MethodGet, "/admin/x")
+	router.Remove(http.MethodGet, "/x/y/z")
+	router.GET("/admin/y", handler)
+
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/admin/x", http.StatusNotFound, ""},
+		{"/admin/y", http.StatusOK, "[g]/admin/y"},
+		{"/x/y/z", http.StatusNotFound, ""},
+		{"/x/y/w", http.StatusOK, "[g]/x/y/w"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if w.Code != tt.code {
+				t.Errorf("expected status %d, got %d", tt.code, w.Code)
+			}
+			if tt.body != "" && w.Body.String() != tt.body {
+				t.Errorf("expected %s, got %s", tt.body, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestOptionalParams(t *testing.T) {
+	t.Parallel()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params, _ := context.Parameters(r.Context())
+
+		year, err := params.Int("year")
+		if errors.Is(err, context.ErrParamNotFound) {
+			_, _ = fmt.Fprint(w, "all")
+			return
+		}
+
+		_, _ = fmt.Fprintf(w, "%d %t", year, params.Has("month"))
+	})
+
+	router := New()
+	router.GET("/reports/{year?:[0-9]+}/{month?}", handler, "reports")
+
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/reports", http.StatusOK, "all"},
+		{"/reports/2024", http.StatusOK, "2024 false"},
+		{"/reports/2024/05", http.StatusOK, "2024 true"},
+		{"/reports/latest", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if w.Code != tt.code {
+				t.Errorf("expected status %d, got %d", tt.code, w.Code)
+			}
+			if tt.body != "" && w.Body.String() != tt.body {
+				t.Errorf("expected %s, got %s", tt.body, w.Body.String())
+			}
+		})
+	}
+
+	if url, err := router.URL("reports", "year", "2024"); err != nil || url != "/reports/2024" {
+		t.Errorf("expected /reports/2024, got %q %v", url, err)
+	}
+	if _, err := router.URL("reports", "month", "5"); err == nil {
+		t.Error("expected param of optional wildcard following missing one to be reported")
+	}
+
+	router.POST("/{page?}", handler, "page")
+	for url, params := range map[string][]string{"/": nil, "/2": {"page", "2"}} {
+		if got, err := router.URL("page", params...); err != nil || got != url {
+			t.Errorf("expected %s, got %q %v", url, got, err)
+		}
+	}
+
+	if err := router.TryHandle(http.MethodGet, "/reports", handler); err == nil {
+		t.Error("expected expanded path to conflict")
+	}
+
+	if !router.Remove(http.MethodGet, "/reports/{year?:[0-9]+}/{month?}") || !router.Remove(http.MethodPost, "/{page?}") {
+		t.Fatal("expected routes to be removed")
+	}
+	if routes := router.Routes(); len(routes) != 0 {
+		t.Errorf("expected routes of all expanded paths to be removed, got %+v", routes)
+	}
+}
+
+func TestTemplateParams(t *testing.T) {
+	t.Parallel()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params, _ := context.Parameters(r.Context())
+		_, _ = fmt.Fprintf(w, "%s %s", params.Value("name"), params.Value("ext"))
+	})
+
+	router := New()
+	router.GET("/files/{name}.{ext:[a-z]+}", handler, "file")
+	router.GET("/files/{name}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, "plain")
+	}))
+
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/files/archive.tar.gz", http.StatusOK, "archive.tar gz"},
+		{"/files/report.pdf", http.StatusOK, "report pdf"},
+		{"/files/report.123", http.StatusOK, "plain"},
+		{"/files/report", http.StatusOK, "plain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if w.Code != tt.code {
+				t.Errorf("expected status %d, got %d", tt.code, w.Code)
+			}
+			if w.Body.String() != tt.body {
+				t.Errorf("expected %s, got %s", tt.body, w.Body.String())
+			}
+		})
+	}
+
+	if url, err := router.URL("file", "name", "report", "ext", "pdf"); err != nil || url != "/files/report.pdf" {
+		t.Errorf("expected /files/report.pdf, got %q %v", url, err)
+	}
+
+	if !strings.Contains(router.PrettyPrint(), "{name}.{ext:[a-z]+}") {
+		t.Errorf("PrettyPrint() should contain template node: %s", router.PrettyPrint())
+	}
+
+	if err := router.TryHandle(http.MethodGet, "/files/{file}.{type:[a-z]+}", handler); err == nil {
+		t.Error("expected template matching the same values to conflict")
+	}
+}
+
+func TestGroupScope(t *testing.T) {
+	t.Parallel()
+
+	router := New().(*router)
+	router.TrailingSlash(TrailingSlashStrict)
+	router.GET("/x", &mockHandler{})
+
+	router.Group("/api", func(api RouteGroup) {
+		api.GET("/", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = fmt.Fprint(w, "api")
+		}))
+		api.GET("/users", &mockHandler{})
+
+		api.Host("admin.example.com").GET("/stats", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = fmt.Fprint(w, "stats")
+		}))
+
+		var patterns []string
+		for _, info := range api.Routes() {
+			patterns = append(patterns, info.Host+info.Pattern)
+		}
+		if !reflect.DeepEqual(patterns, []string{"/api", "/api/users", "admin.example.com/api/stats"}) {
+			t.Errorf("expected routes under the prefix only, got %v", patterns)
+		}
+	})
+
+	tests := []struct {
+		host string
+		path string
+		body string
+	}{
+		{"example.com", "/api", "api"},
+		{"admin.example.com", "/api/stats", "stats"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		req.Host = tt.host
+
+		router.ServeHTTP(w, req)
+
+		if w.Body.String() != tt.body {
+			t.Errorf("%s%s: expected %s, got %s", tt.host, tt.path, tt.body, w.Body.String())
+		}
+	}
+}
+
+func TestGroupMiddlewareScope(t *testing.T) {
+	t.Parallel()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, r.URL.Path)
+	})
+
+	for _, compiled := range []bool{false, true} {
+		router := New()
+		router.Group("/api/v1", func(g RouteGroup) {
+			g.USE(http.MethodGet, "/", mockMiddleware("[v1]"))
+			g.GET("/users", handler)
+		})
+		router.GET("/api/v10/public", handler)
+
+		if compiled {
+			router.Compile()
+		}
+
+		tests := []struct {
+			path string
+			body string
+		}{
+			{"/api/v1/users", "[v1]/api/v1/users"},
+			{"/api/v10/public", "/api/v10/public"},
+		}
+		for _, tt := range tests {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if w.Body.String() != tt.body {
+				t.Errorf("compiled %t %s: expected %s, got %s", compiled, tt.path, tt.body, w.Body.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package gorouter
+
+import (
+	"net/http"
+
+	"github.com/valyala/fasthttp"
+)
+
+// Option configures net/http router created with NewWithOptions
+type Option interface {
+	applyNetHTTP(c *config)
+}
+
+// FastHTTPOption configures fasthttp router created with NewFastHTTPRouterWithOptions
+type FastHTTPOption interface {
+	applyFastHTTP(c *config)
+}
+
+// CommonOption configures both net/http and fasthttp router
+type CommonOption func(*config)
+
+func (o CommonOption) applyNetHTTP(c *config)  { o(c) }
+func (o CommonOption) applyFastHTTP(c *config) { o(c) }
+
+// netHTTPOption configures net/http router only, fasthttp router does not accept it
+type netHTTPOption func(*config)
+
+func (o netHTTPOption) applyNetHTTP(c *config) { o(c) }
+
+// fastHTTPOption configures fasthttp router only, net/http router does not accept it
+type fastHTTPOption func(*config)
+
+func (o fastHTTPOption) applyFastHTTP(c *config) { o(c) }
+
+type config struct {
+	middleware        []MiddlewareFunc
+	notFound          http.Handler
+	notAllowed        http.Handler
+	panicHandler      func(http.ResponseWriter, *http.Request, interface{})
+	errorHandler      func(http.ResponseWriter, *http.Request, error)
+	optionsHandler    http.Handler
+	trailingSlash     TrailingSlashPolicy
+	headFallback      bool
+	autoOptions       bool
+	redirectCleanPath bool
+	routePattern      bool
+	rawPath           bool
+	concurrent        bool
+
+	fastHTTPMiddleware     []FastHTTPMiddlewareFunc
+	fastHTTPNotFound       fasthttp.RequestHandler
+	fastHTTPNotAllowed     fasthttp.RequestHandler
+	fastHTTPPanicHandler   func(*fasthttp.RequestCtx, interface{})
+	fastHTTPErrorHandler   func(*fasthttp.RequestCtx, error)
+	fastHTTPOptionsHandler fasthttp.RequestHandler
+}
+
+func newConfig() *config {
+	return &config{
+		headFallback: true,
+		autoOptions:  true,
+	}
+}
+
+func newNetHTTPConfig(opts ...Option) *config {
+	c := newConfig()
+	for _, opt := range opts {
+		opt.applyNetHTTP(c)
+	}
+
+	return c
+}
+
+func newFastHTTPConfig(opts ...FastHTTPOption) *config {
+	c := newConfig()
+	for _, opt := range opts {
+		opt.applyFastHTTP(c)
+	}
+
+	return c
+}
+
+// WithMiddleware adds global middleware to net/http router
+func WithMiddleware(fs ...MiddlewareFunc) Option {
+	return netHTTPOption(func(c *config) {
+		c.middleware = append(c.middleware, fs...)
+	})
+}
+
+// WithFastHTTPMiddleware adds global middleware to fasthttp router
+func WithFastHTTPMiddleware(fs ...FastHTTPMiddlewareFunc) FastHTTPOption {
+	return fastHTTPOption(func(c *config) {
+		c.fastHTTPMiddleware = append(c.fastHTTPMiddleware, fs...)
+	})
+}
+
+// WithNotFound sets net/http router handler replying to the request with the 404 Error code
+func WithNotFound(h http.Handler) Option {
+	return netHTTPOption(func(c *config) {
+		c.notFound = h
+	})
+}
+
+// WithFastHTTPNotFound sets fasthttp router handler replying to the request with the 404 Error code
+func WithFastHTTPNotFound(h fasthttp.RequestHandler) FastHTTPOption {
+	return fastHTTPOption(func(c *config) {
+		c.fastHTTPNotFound = h
+	})
+}
+
+// WithNotAllowed sets net/http router handler replying to the request with the 405 Error code
+func WithNotAllowed(h http.Handler) Option {
+	return netHTTPOption(func(c *config) {
+		c.notAllowed = h
+	})
+}
+
+// WithFastHTTPNotAllowed sets fasthttp router handler replying to the request with the 405 Error code
+func WithFastHTTPNotAllowed(h fasthttp.RequestHandler) FastHTTPOption {
+	return fastHTTPOption(func(c *config) {
+		c.fastHTTPNotAllowed = h
+	})
+}
+
+// WithPanicHandler sets net/http router handler replying to the request
+// when panic is recovered, global middleware panics are recovered as well
+func WithPanicHandler(fn func(w http.ResponseWriter, r *http.Request, rcv interface{})) Option {
+	return netHTTPOption(func(c *config) {
+		c.panicHandler = fn
+	})
+}
+
+// WithFastHTTPPanicHandler sets fasthttp router handler replying to the request
+// when panic is recovered, global middleware panics are recovered as well
+func WithFastHTTPPanicHandler(fn func(ctx *fasthttp.RequestCtx, rcv interface{})) FastHTTPOption {
+	return fastHTTPOption(func(c *config) {
+		c.fastHTTPPanicHandler = fn
+	})
+}
+
+// WithErrorHandler sets net/http router handler replying to the request
+// when error returning handler registered with HandleE fails or panics, other handlers are not covered
+func WithErrorHandler(fn func(w http.ResponseWriter, r *http.Request, err error)) Option {
+	return netHTTPOption(func(c *config) {
+		c.errorHandler = fn
+	})
+}
+
+// WithFastHTTPErrorHandler sets fasthttp router handler replying to the request
+// when error returning handler registered with HandleE fails or panics, other handlers are not covered
+func WithFastHTTPErrorHandler(fn func(ctx *fasthttp.RequestCtx, err error)) FastHTTPOption {
+	return fastHTTPOption(func(c *config) {
+		c.fastHTTPErrorHandler = fn
+	})
+}
+
+// WithTrailingSlash sets trailing slash policy, TrailingSlashLenient by default
+func WithTrailingSlash(policy TrailingSlashPolicy) CommonOption {
+	return CommonOption(func(c *config) {
+		c.trailingSlash = policy
+	})
+}
+
+// WithRedirectCleanPath enables redirect of requests with not clean path to the cleaned one
+func WithRedirectCleanPath(redirect bool) CommonOption {
+	return CommonOption(func(c *config) {
+		c.redirectCleanPath = redirect
+	})
+}
+
+// WithHeadFallback toggles serving HEAD requests with matching GET routes, enabled by default
+func WithHeadFallback(enabled bool) CommonOption {
+	return CommonOption(func(c *config) {
+		c.headFallback = enabled
+	})
+}
+
+// WithAutoOptions toggles replying to OPTIONS requests with Allow header
+// when no OPTIONS route is registered, enabled by default,
+// when disabled such requests are replied with the 405 Error code
+func WithAutoOptions(enabled bool) CommonOption {
+	return CommonOption(func(c *config) {
+		c.autoOptions = enabled
+	})
+}
+
+// WithAutoOptionsHandler sets net/http router handler replying to OPTIONS requests
+// when no OPTIONS route is registered, Allow header is set before it is called
+func WithAutoOptionsHandler(h http.Handler) Option {
+	return netHTTPOption(func(c *config) {
+		c.optionsHandler = h
+	})
+}
+
+// WithFastHTTPAutoOptionsHandler sets fasthttp router handler replying to OPTIONS requests
+// when no OPTIONS route is registered, Allow header is set before it is called
+func WithFastHTTPAutoOptionsHandler(h fasthttp.RequestHandler) FastHTTPOption {
+	return fastHTTPOption(func(c *config) {
+		c.fastHTTPOptionsHandler = h
+	})
+}
+
+// WithRoutePattern toggles storing matched route method and pattern for route middleware and handlers,
+// available with context.RoutePattern for net/http router and FastHTTPRoutePattern for fasthttp router
+func WithRoutePattern(enabled bool) CommonOption {
+	return CommonOption(func(c *config) {
+		c.routePattern = enabled
+	})
+}
+
+// WithRawPath toggles matching routes against percent-encoded request path,
+// encoded slashes do not split path parts and params values are unescaped individually,
+// other encoded characters are decoded before matching so static route parts are matched as registered
+func WithRawPath(enabled bool) CommonOption {
+	return CommonOption(func(c *config) {
+		c.rawPath = enabled
+	})
+}
+
+// WithConcurrentUpdates toggles changing routes while router serves requests,
+// every change is applied to a copy of the routes tree published atomically once done,
+// requests are always served with a complete routes tree, either the previous or the new one
+func WithConcurrentUpdates(enabled bool) CommonOption {
+	return CommonOption(func(c *config) {
+		c.concurrent = enabled
+	})
+}
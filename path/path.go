@@ -1,6 +1,12 @@
 package path
 
-import "strings"
+import (
+	"fmt"
+	stdpath "path"
+	"strings"
+
+	"github.com/vardius/gorouter/v4/internal/format"
+)
 
 // TrimSlash trims '/' URL path
 func TrimSlash(path string) string {
@@ -17,6 +23,57 @@ func TrimSlash(path string) string {
 	return path
 }
 
+// Clean returns the shortest path equivalent to given one (see path.Clean)
+// keeping its trailing slash, paths not starting with slash are returned unchanged
+func Clean(path string) string {
+	if len(path) == 0 || path[0] != '/' {
+		return path
+	}
+
+	cleaned := stdpath.Clean(path)
+	if cleaned != "/" && path[len(path)-1] == '/' {
+		cleaned += "/"
+	}
+
+	return cleaned
+}
+
+// Unescape decodes percent-encoded path keeping encoded slashes and percent signs,
+// so path parts are not changed and can be unescaped individually, invalid escapes are kept
+func Unescape(path string) string {
+	if strings.IndexByte(path, '%') < 0 {
+		return path
+	}
+
+	var b strings.Builder
+	b.Grow(len(path))
+
+	for i := 0; i < len(path); i++ {
+		if path[i] == '%' && i+2 < len(path) && format.IsHexByte(path[i+1]) && format.IsHexByte(path[i+2]) {
+			if c := unhex(path[i+1])<<4 | unhex(path[i+2]); c != '/' && c != '%' {
+				b.WriteByte(c)
+				i += 2
+				continue
+			}
+		}
+
+		b.WriteByte(path[i])
+	}
+
+	return b.String()
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
+
 // GetPart returns first path part and next path as a second argument
 func GetPart(path string) (part string, nextPath string) {
 	if j := strings.IndexByte(path, '/'); j > 0 {
@@ -30,11 +87,13 @@ func GetPart(path string) (part string, nextPath string) {
 	return
 }
 
-// GetNameFromPart gets node name from path part
+// GetNameFromPart gets node name from path part,
+// exp is either regexp or constraint name, e.g. {id:[0-9]+} or {id:int},
+// template part is named with the whole part, e.g. {name}.{ext}
 func GetNameFromPart(pathPart string) (name string, exp string) {
 	name = pathPart
 
-	if pathPart[0] == '{' {
+	if pathPart[0] == '{' && !IsTemplate(pathPart) {
 		name = pathPart[1 : len(pathPart)-1]
 
 		if parts := strings.Split(name, ":"); len(parts) == 2 {
@@ -42,6 +101,10 @@ func GetNameFromPart(pathPart string) (name string, exp string) {
 			exp = parts[1]
 		}
 
+		if exp == "" {
+			name = strings.TrimSuffix(name, "*")
+		}
+
 		if name == "" {
 			panic("Empty wildcard name")
 		}
@@ -52,6 +115,127 @@ func GetNameFromPart(pathPart string) (name string, exp string) {
 	return
 }
 
+// IsTemplate checks if path part combines literals with params or consists of many params,
+// e.g. {name}.{ext} or v{version}
+func IsTemplate(pathPart string) bool {
+	start := strings.IndexByte(pathPart, '{')
+	if start < 0 {
+		return false
+	}
+	if start > 0 {
+		return true
+	}
+
+	end := ClosingBrace(pathPart, 0)
+
+	return end > 0 && end < len(pathPart)-1
+}
+
+// ClosingBrace returns index of the brace closing the one opened at given index,
+// braces of regexp quantifiers are taken into account, -1 if brace is not closed
+func ClosingBrace(pathPart string, start int) int {
+	depth := 0
+	for i := start; i < len(pathPart); i++ {
+		switch pathPart[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+// IsCatchAll checks if path part is a catch-all wildcard {name*}
+// matching the rest of the path
+func IsCatchAll(pathPart string) bool {
+	partLength := len(pathPart)
+
+	return partLength > 3 &&
+		pathPart[0] == '{' &&
+		pathPart[partLength-2] == '*' &&
+		pathPart[partLength-1] == '}' &&
+		strings.IndexByte(pathPart, ':') < 0
+}
+
+// ExpandOptional expands path with optional trailing wildcards, e.g. /reports/{year?}/{month?},
+// into paths without and with each of them, from the shortest to the longest one,
+// path without optional wildcards is returned unchanged, panics if required part follows optional one
+func ExpandOptional(path string) []string {
+	paths, err := expandOptional(path)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return paths
+}
+
+// CheckOptional checks if path can be expanded, required part can not follow optional one
+func CheckOptional(path string) error {
+	_, err := expandOptional(path)
+
+	return err
+}
+
+func expandOptional(path string) ([]string, error) {
+	if strings.IndexByte(path, '?') < 0 {
+		return []string{path}, nil
+	}
+
+	parts := strings.Split(path, "/")
+	firstOptional := -1
+
+	for i, part := range parts {
+		if required, ok := requiredPart(part); ok {
+			if firstOptional < 0 {
+				firstOptional = i
+			}
+			parts[i] = required
+		} else if firstOptional >= 0 && part != "" {
+			return nil, fmt.Errorf("required path part %s can not follow optional one", part)
+		}
+	}
+
+	if firstOptional < 0 {
+		return []string{path}, nil
+	}
+
+	// trailing slash is kept with the longest path only
+	last := len(parts)
+	if parts[last-1] == "" {
+		last--
+	}
+
+	paths := make([]string, 0, last-firstOptional+1)
+	for i := firstOptional; i < last; i++ {
+		paths = append(paths, strings.Join(parts[:i], "/"))
+	}
+
+	return append(paths, strings.Join(parts, "/")), nil
+}
+
+// requiredPart removes optional marker from wildcard path part, e.g. {year?} or {year?:[0-9]+},
+// reports if part was optional
+func requiredPart(part string) (string, bool) {
+	if len(part) < 3 || part[0] != '{' || part[len(part)-1] != '}' || IsTemplate(part) {
+		return part, false
+	}
+
+	end := strings.IndexByte(part, ':')
+	if end < 0 {
+		end = len(part) - 1
+	}
+	if part[end-1] != '?' {
+		return part, false
+	}
+
+	return part[:end-1] + part[end:], true
+}
+
 func StripLeadingSlashes(path string, stripSlashes int) string {
 	for stripSlashes > 0 && len(path) > 0 {
 		n := strings.IndexByte(path[1:], '/')
@@ -1,6 +1,7 @@
 package path
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -35,6 +36,58 @@ func TestTrim(t *testing.T) {
 	}
 }
 
+func TestClean(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"empty", "", ""},
+		{"asterisk", "*", "*"},
+		{"/", "/", "/"},
+		{"//", "//", "/"},
+		{"/x", "/x", "/x"},
+		{"/x/", "/x/", "/x/"},
+		{"/x//y", "/x//y", "/x/y"},
+		{"/x/./y", "/x/./y", "/x/y"},
+		{"/a//b/../c", "/a//b/../c", "/a/c"},
+		{"/a/b/../c/", "/a/b/../c/", "/a/c/"},
+		{"/..", "/..", "/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Clean(tt.path); got != tt.want {
+				t.Errorf("[%s] Clean() = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnescape(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"empty", "", ""},
+		{"not encoded", "/x/y", "/x/y"},
+		{"encoded slash", "/a%2Fb/c", "/a%2Fb/c"},
+		{"lowercase encoded slash", "/a%2fb", "/a%2fb"},
+		{"encoded percent", "/100%25", "/100%25"},
+		{"double encoded slash", "/a%252Fb", "/a%252Fb"},
+		{"encoded space", "/a%20b", "/a b"},
+		{"unicode", "/caf%C3%A9/%E2%9C%93", "/café/✓"},
+		{"invalid escape", "/a%zzb%2", "/a%zzb%2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Unescape(tt.path); got != tt.want {
+				t.Errorf("[%s] Unescape() = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestGetPart(t *testing.T) {
 	type args struct {
 		path string
@@ -76,6 +129,8 @@ func TestGetNameFromPart(t *testing.T) {
 		{"x", args{"x"}, "x"},
 		{"{name}", args{"{name}"}, "name"},
 		{"{name:(w+)", args{"{name:(w+)"}, "name"},
+		{"{name*}", args{"{name*}"}, "name"},
+		{"{name:[a-z]*}", args{"{name:[a-z]*}"}, "name"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -87,6 +142,28 @@ func TestGetNameFromPart(t *testing.T) {
 	}
 }
 
+func TestIsCatchAll(t *testing.T) {
+	tests := []struct {
+		name     string
+		pathPart string
+		want     bool
+	}{
+		{"static", "x", false},
+		{"static with asterisk", "x*", false},
+		{"wildcard", "{name}", false},
+		{"regexp", "{name:[a-z]*}", false},
+		{"catch-all", "{name*}", true},
+		{"empty catch-all", "{*}", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsCatchAll(tt.pathPart); got != tt.want {
+				t.Errorf("IsCatchAll() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestStripLeadingSlashes(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -106,3 +183,59 @@ func TestStripLeadingSlashes(t *testing.T) {
 		})
 	}
 }
+
+func TestExpandOptional(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want []string
+	}{
+		{"no optional", "/reports/{year}", []string{"/reports/{year}"}},
+		{"regexp quantifier", "/reports/{year:[0-9]?}", []string{"/reports/{year:[0-9]?}"}},
+		{"optional", "/reports/{year?}", []string{"/reports", "/reports/{year}"}},
+		{"optional regexp", "/reports/{year?:[0-9]+}", []string{"/reports", "/reports/{year:[0-9]+}"}},
+		{"many optional", "/reports/{year?}/{month?}", []string{"/reports", "/reports/{year}", "/reports/{year}/{month}"}},
+		{"trailing slash", "/reports/{year?}/", []string{"/reports", "/reports/{year}/"}},
+		{"root", "/{page?}", []string{"", "/{page}"}},
+		{"without leading slash", "GET/{page?}", []string{"GET", "GET/{page}"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExpandOptional(tt.path); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExpandOptional() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandOptionalRequiredAfterOptional(t *testing.T) {
+	defer func() {
+		if rcv := recover(); rcv == nil {
+			t.Error("expected panic")
+		}
+	}()
+
+	ExpandOptional("/reports/{year?}/summary")
+}
+
+func TestIsTemplate(t *testing.T) {
+	tests := []struct {
+		part string
+		want bool
+	}{
+		{"static", false},
+		{"{name}", false},
+		{"{year:[0-9]{4}}", false},
+		{"{name}.{ext}", true},
+		{"{id}.png", true},
+		{"v{version}", true},
+		{"{a}{b}", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.part, func(t *testing.T) {
+			if got := IsTemplate(tt.part); got != tt.want {
+				t.Errorf("IsTemplate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,7 +1,24 @@
 package gorouter
 
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/vardius/gorouter/v4/context"
+	"github.com/vardius/gorouter/v4/mux"
+	pathutils "github.com/vardius/gorouter/v4/path"
+)
+
 type route struct {
-	handler interface{}
+	handler       interface{}
+	method        string
+	name          string
+	trailingSlash bool // route pattern ends with slash
+	subrouter     bool // mounted handler, matches any trailing slash
+	pattern       context.Route
 }
 
 func newRoute(h interface{}) *route {
@@ -15,6 +32,255 @@ func newRoute(h interface{}) *route {
 }
 
 func (r *route) Handler() interface{} {
-	// returns already cached computed handler
 	return r.handler
 }
+
+// matchesSlash checks if request path trailing slash matches the route pattern one
+func (r *route) matchesSlash(path string) bool {
+	return r.subrouter || r.trailingSlash == hasTrailingSlash(path)
+}
+
+// casedPath returns path matched by the route case-insensitively with static parts in the route pattern casing,
+// reports if it differs from the given one, params are values matched for the path
+func (r *route) casedPath(path string, params context.Params) (string, bool) {
+	var b strings.Builder
+
+	pattern := pathutils.TrimSlash(r.pattern.Pattern)
+	differs := false
+	rest := path
+
+	for rest != "" && pattern != "" {
+		var patternPart, part string
+		if patternPart, pattern = pathutils.GetPart(pattern); pathutils.IsCatchAll(patternPart) {
+			break // the rest of the path is a param value
+		}
+
+		start := len(path) - len(rest)
+		part, rest = pathutils.GetPart(rest)
+
+		cased := casedPart(patternPart, part, params)
+		if !differs {
+			if cased == part {
+				continue
+			}
+
+			// allocate only when path has to be changed
+			differs = true
+			b.WriteString(path[:start])
+		} else {
+			b.WriteByte('/')
+		}
+
+		b.WriteString(cased)
+	}
+
+	if !differs {
+		return path, false
+	}
+
+	if rest != "" {
+		b.WriteByte('/')
+		b.WriteString(rest)
+	}
+
+	return b.String(), true
+}
+
+// casedPart returns path part in the route pattern part casing, param values are kept as they are
+func casedPart(patternPart, part string, params context.Params) string {
+	switch {
+	case pathutils.IsTemplate(patternPart):
+		var b strings.Builder
+		for patternPart != "" {
+			start := strings.IndexByte(patternPart, '{')
+			if start < 0 {
+				b.WriteString(patternPart)
+				break
+			}
+
+			end := pathutils.ClosingBrace(patternPart, start)
+			name, _ := pathutils.GetNameFromPart(patternPart[start : end+1])
+
+			b.WriteString(patternPart[:start])
+			b.WriteString(params.Value(name))
+			patternPart = patternPart[end+1:]
+		}
+
+		return b.String()
+	case patternPart[0] == '{':
+		return part
+	default:
+		return patternPart
+	}
+}
+
+func hasTrailingSlash(path string) bool {
+	return len(path) > 1 && path[len(path)-1] == '/'
+}
+
+// cleanPattern adds leading slash to the route pattern and cleans it keeping its trailing slash,
+// so the route is registered and looked up by the same pattern however it is written
+func cleanPattern(pattern string) string {
+	if len(pattern) == 0 || pattern[0] != '/' {
+		pattern = "/" + pattern
+	}
+
+	return pathutils.Clean(pattern)
+}
+
+// toggleTrailingSlash adds trailing slash to the path or removes it if present
+func toggleTrailingSlash(path string) string {
+	if hasTrailingSlash(path) {
+		return path[:len(path)-1]
+	}
+
+	return path + "/"
+}
+
+// redirectCode returns redirect status code keeping request method for non GET/HEAD requests
+func redirectCode(method string) int {
+	if method == http.MethodGet || method == http.MethodHead {
+		return http.StatusMovedPermanently
+	}
+
+	return http.StatusPermanentRedirect
+}
+
+// escapePath percent-encodes path parts, already encoded characters are kept encoded
+func escapePath(path string) string {
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		if unescaped, err := url.PathUnescape(part); err == nil {
+			part = unescaped
+		}
+
+		parts[i] = url.PathEscape(part)
+	}
+
+	return strings.Join(parts, "/")
+}
+
+// unescapeParams decodes values of params matched against percent-encoded path
+func unescapeParams(params context.Params) {
+	for i := range params {
+		if value, err := url.PathUnescape(params[i].Value); err == nil {
+			params[i].Value = value
+		}
+	}
+}
+
+// RouteInfo describes registered route
+type RouteInfo struct {
+	Method string
+	// Host pattern of the scoped router, empty for the default one
+	Host string
+	// Pattern the route was registered with, e.g. /users/{id:[0-9]+}
+	Pattern string
+	// Name of the route, empty if not named
+	Name string
+	// Params names in order of appearance
+	Params []string
+	// Constraints maps params names to their regexps or constraints names
+	Constraints map[string]string
+	// Middleware is a number of middleware attached to the route pattern and its parents,
+	// global middleware is not included
+	Middleware int
+	// Subrouter is true for mounted handlers
+	Subrouter bool
+}
+
+func newRouteInfo(method string, r *route, info mux.RouteInfo) RouteInfo {
+	return RouteInfo{
+		Method:      method,
+		Pattern:     r.pattern.Pattern,
+		Name:        r.name,
+		Params:      info.Params,
+		Constraints: info.Constraints,
+		Middleware:  info.Middleware,
+		Subrouter:   info.Subrouter,
+	}
+}
+
+// namedRoutes maps route names to registered routes
+type namedRoutes map[string]*route
+
+// check validates optional route name, only one name can be given
+func (n namedRoutes) check(name []string) error {
+	switch len(name) {
+	case 0:
+		return nil
+	case 1:
+		if name[0] == "" {
+			return errors.New("route name can not be empty")
+		}
+		if _, ok := n[name[0]]; ok {
+			return fmt.Errorf("route name already registered: %s", name[0])
+		}
+		return nil
+	default:
+		return errors.New("route can have only one name")
+	}
+}
+
+// add registers route under optional name, name has to be checked first
+func (n namedRoutes) add(method string, r *route, name []string) {
+	if len(name) == 0 {
+		return
+	}
+
+	r.method = method
+	r.name = name[0]
+	n[r.name] = r
+}
+
+// remove unregisters route name if it has one
+func (n namedRoutes) remove(r *route) {
+	if r.name != "" && n[r.name] == r {
+		delete(n, r.name)
+	}
+}
+
+// ConflictError describes route which can not be registered because
+// it is ambiguous with or shadowed by already registered one
+type ConflictError struct {
+	Method  string
+	Pattern string
+	// ExistingMethod and ExistingPattern describe already registered route
+	ExistingMethod  string
+	ExistingPattern string
+	// Reason describes the conflict
+	Reason string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("route %s %s conflicts with %s %s: %s", e.Method, e.Pattern, e.ExistingMethod, e.ExistingPattern, e.Reason)
+}
+
+// checkRoute checks if route can be added to the method tree under given pattern
+func checkRoute(t mux.Tree, method, pattern string, subrouter bool) error {
+	var err error
+	if subrouter {
+		err = t.CheckSubrouter(method + pattern)
+	} else {
+		err = t.CheckRoute(method + pattern)
+	}
+
+	var conflict *mux.ConflictError
+	if !errors.As(err, &conflict) {
+		return err
+	}
+
+	e := &ConflictError{
+		Method:          method,
+		Pattern:         pattern,
+		ExistingMethod:  method,
+		ExistingPattern: "/" + strings.TrimPrefix(strings.TrimPrefix(conflict.Existing, method), "/"),
+		Reason:          conflict.Reason,
+	}
+
+	if r, ok := conflict.Route.(*route); ok {
+		e.ExistingMethod, e.ExistingPattern = r.pattern.Method, r.pattern.Pattern
+	}
+
+	return e
+}
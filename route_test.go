@@ -85,3 +85,44 @@ func TestNilHandler(t *testing.T) {
 		t.Error("Router should panic if handler is nil")
 	}
 }
+
+func TestRouteCasedPath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		params  context.Params
+		cased   string
+		differs bool
+	}{
+		{"/users/{name}", "users/John", context.Params{{Key: "name", Value: "John"}}, "users/John", false},
+		{"/users/{name}", "USERS/John", context.Params{{Key: "name", Value: "John"}}, "users/John", true},
+		{"/api/Users/{name}/posts", "api/users/John/Posts", context.Params{{Key: "name", Value: "John"}}, "api/Users/John/posts", true},
+		{"/files/{path*}", "FILES/A/b", context.Params{{Key: "path", Value: "A/b"}}, "files/A/b", true},
+		{"/files/{name}.PDF", "files/Report.pdf", context.Params{{Key: "name", Value: "Report"}}, "files/Report.PDF", true},
+		{"/reports/{year?}", "Reports", nil, "reports", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			r := newRoute(http.NotFoundHandler())
+			r.pattern = context.Route{Method: http.MethodGet, Pattern: tt.pattern}
+
+			cased, differs := r.casedPath(tt.path, tt.params)
+			if cased != tt.cased || differs != tt.differs {
+				t.Errorf("expected %s %t, got %s %t", tt.cased, tt.differs, cased, differs)
+			}
+		})
+	}
+}
+
+func TestRouteCasedPathAllocs(t *testing.T) {
+	r := newRoute(http.NotFoundHandler())
+	r.pattern = context.Route{Method: http.MethodGet, Pattern: "/api/users/{name}/posts"}
+	params := context.Params{{Key: "name", Value: "John"}}
+
+	if allocs := testing.AllocsPerRun(100, func() {
+		r.casedPath("api/users/John/posts", params)
+	}); allocs != 0 {
+		t.Errorf("expected path in the registered casing to be checked without allocations, got %v", allocs)
+	}
+}
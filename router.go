@@ -12,46 +12,61 @@ type MiddlewareFunc func(http.Handler) http.Handler
 // FastHTTPMiddlewareFunc is a fasthttp middleware function type
 type FastHTTPMiddlewareFunc func(fasthttp.RequestHandler) fasthttp.RequestHandler
 
+// TrailingSlashPolicy defines how trailing slash of the request path
+// is matched against the trailing slash of the route pattern
+type TrailingSlashPolicy int
+
+const (
+	// TrailingSlashLenient matches routes regardless of the trailing slash
+	TrailingSlashLenient TrailingSlashPolicy = iota
+	// TrailingSlashStrict matches routes only if the request path trailing slash
+	// is the same as the route pattern one
+	TrailingSlashStrict
+	// TrailingSlashRedirect redirects requests to the route pattern trailing slash form,
+	// with 301 code for GET and HEAD requests and 308 code for other methods
+	TrailingSlashRedirect
+)
+
 // Router is a micro framework, HTTP request router, multiplexer, mux
 type Router interface {
 	// PrettyPrint prints the tree text representation to console
 	PrettyPrint() string
 
 	// POST adds http.Handler as router handler
-	// under POST method and given patter
-	POST(pattern string, handler http.Handler)
+	// under POST method and given patter with optional route name
+	POST(pattern string, handler http.Handler, name ...string)
 
 	// GET adds http.Handler as router handler
-	// under GET method and given patter
-	GET(pattern string, handler http.Handler)
+	// under GET method and given patter with optional route name
+	GET(pattern string, handler http.Handler, name ...string)
 
 	// PUT adds http.Handler as router handler
-	// under PUT method and given patter
-	PUT(pattern string, handler http.Handler)
+	// under PUT method and given patter with optional route name
+	PUT(pattern string, handler http.Handler, name ...string)
 
 	// DELETE adds http.Handler as router handler
-	// under DELETE method and given patter
-	DELETE(pattern string, handler http.Handler)
+	// under DELETE method and given patter with optional route name
+	DELETE(pattern string, handler http.Handler, name ...string)
 
 	// PATCH adds http.Handler as router handler
-	// under PATCH method and given patter
-	PATCH(pattern string, handler http.Handler)
+	// under PATCH method and given patter with optional route name
+	PATCH(pattern string, handler http.Handler, name ...string)
 
 	// OPTIONS adds http.Handler as router handler
-	// under OPTIONS method and given patter
-	OPTIONS(pattern string, handler http.Handler)
+	// under OPTIONS method and given patter with optional route name
+	OPTIONS(pattern string, handler http.Handler, name ...string)
 
 	// HEAD adds http.Handler as router handler
-	// under HEAD method and given patter
-	HEAD(pattern string, handler http.Handler)
+	// under HEAD method and given patter with optional route name
+	HEAD(pattern string, handler http.Handler, name ...string)
 
 	// CONNECT adds http.Handler as router handler
-	// under CONNECT method and given patter
-	CONNECT(pattern string, handler http.Handler)
+	// under CONNECT method and given patter with optional route name
+	CONNECT(pattern string, handler http.Handler, name ...string)
 
 	// TRACE adds http.Handler as router handler
-	// under TRACE method and given patter
-	TRACE(pattern string, handler http.Handler)
+	// under TRACE method and given patter with optional route name
+	TRACE(pattern string, handler http.Handler, name ...string)
 
 	// USE adds middleware functions ([]MiddlewareFunc)
 	// to whole router branch under given method and patter
@@ -62,13 +77,59 @@ type Router interface {
 	USEANY(pattern string, fs ...MiddlewareFunc)
 
 	// Handle adds http.Handler as router handler
-	// under given method and patter
-	Handle(method, pattern string, handler http.Handler)
-
-	// Mount another handler as a subrouter
+	// under given method and patter with optional route name,
+	// panics if pattern conflicts with already registered route
+	Handle(method, pattern string, handler http.Handler, name ...string)
+
+	// TryHandle adds http.Handler as router handler like Handle does,
+	// returns *ConflictError instead of panicking when pattern is ambiguous with
+	// or shadowed by already registered route, invalid route name is returned as error too
+	TryHandle(method, pattern string, handler http.Handler, name ...string) error
+
+	// Replace swaps handler of the route registered under given method and exactly the same pattern
+	// keeping its name and middleware, route is registered like with Handle if there is none,
+	// panics if pattern conflicts with already registered route or mounted handler
+	Replace(method, pattern string, handler http.Handler)
+
+	// Remove unregisters route registered under given method and exactly the same pattern,
+	// middleware registered for paths left without routes is removed as well,
+	// mounted handlers are removed per method, reports if route was found
+	Remove(method, pattern string) bool
+
+	// HandleE adds error returning handler as router handler
+	// under given method and patter with optional route name,
+	// returned errors and recovered panics are replied with the error handler
+	HandleE(method, pattern string, handler func(http.ResponseWriter, *http.Request) error, name ...string)
+
+	// URL builds path for the route registered under given name,
+	// params are key value pairs used to fill route wildcards
+	URL(name string, params ...string) (string, error)
+
+	// Mount another handler as a subrouter,
+	// panics if it shadows already registered routes
 	Mount(pattern string, handler http.Handler)
 
+	// Update runs fn publishing all the changes it makes to the Router at once,
+	// with concurrent updates enabled requests are served with routes from before fn
+	// until it returns, host routers publish their own changes
+	Update(fn func(r Router))
+
+	// Group registers routes with given prefix using RouteGroup,
+	// middleware added to the RouteGroup applies under the prefix only
+	Group(prefix string, fn func(g RouteGroup))
+
+	// Host returns Router scoped to requests which host matches given pattern
+	// (e.g. `{tenant}.example.com`), host wildcards are available along with path params,
+	// requests not matching any host are handled by this Router
+	Host(pattern string) Router
+
+	// Routes lists registered routes, routes of scoped host routers are listed
+	// after the ones of this Router ordered by host pattern
+	Routes() []RouteInfo
+
 	// Compile optimizes Tree nodes reducing static nodes depth when possible
+	// and composes route handlers with their middleware ahead of time,
+	// should be called once all routes and middleware are registered
 	Compile()
 
 	// ServeHTTP dispatches the request to the route handler
@@ -84,6 +145,122 @@ type Router interface {
 
 	// NotAllowed replies to the request with the 405 Error code
 	NotAllowed(http.Handler)
+
+	// AutoOptions replies to OPTIONS requests when no OPTIONS route is registered
+	// and automatic OPTIONS replies are enabled, Allow header is set before it is called
+	AutoOptions(http.Handler)
+
+	// OnError sets handler replying to the request when handler registered
+	// with HandleE returns error or panics, by default HTTPError status code is used,
+	// it covers HandleE handlers only, unmatched requests are replied with NotFound and NotAllowed handlers
+	// and panics of other handlers are not recovered by the router unless panic handler option is set
+	OnError(fn func(w http.ResponseWriter, r *http.Request, err error))
+
+	// TrailingSlash sets trailing slash policy, TrailingSlashLenient by default
+	TrailingSlash(policy TrailingSlashPolicy)
+
+	// RedirectCleanPath enables redirect of requests with not clean path
+	// (e.g. `/a//b/../c`) to the cleaned one, disabled by default
+	RedirectCleanPath(redirect bool)
+
+	// CaseInsensitive enables case-insensitive matching of static route parts,
+	// with redirect requests are redirected to the registered path casing,
+	// wildcard and regexp values are kept in their original case
+	CaseInsensitive(redirect bool)
+}
+
+// RouteGroup registers routes of the Router under the path prefix,
+// settings of the whole router can be changed with the Router only
+type RouteGroup interface {
+	// POST adds http.Handler as router handler
+	// under POST method and given patter with optional route name
+	POST(pattern string, handler http.Handler, name ...string)
+
+	// GET adds http.Handler as router handler
+	// under GET method and given patter with optional route name
+	GET(pattern string, handler http.Handler, name ...string)
+
+	// PUT adds http.Handler as router handler
+	// under PUT method and given patter with optional route name
+	PUT(pattern string, handler http.Handler, name ...string)
+
+	// DELETE adds http.Handler as router handler
+	// under DELETE method and given patter with optional route name
+	DELETE(pattern string, handler http.Handler, name ...string)
+
+	// PATCH adds http.Handler as router handler
+	// under PATCH method and given patter with optional route name
+	PATCH(pattern string, handler http.Handler, name ...string)
+
+	// OPTIONS adds http.Handler as router handler
+	// under OPTIONS method and given patter with optional route name
+	OPTIONS(pattern string, handler http.Handler, name ...string)
+
+	// HEAD adds http.Handler as router handler
+	// under HEAD method and given patter with optional route name
+	HEAD(pattern string, handler http.Handler, name ...string)
+
+	// CONNECT adds http.Handler as router handler
+	// under CONNECT method and given patter with optional route name
+	CONNECT(pattern string, handler http.Handler, name ...string)
+
+	// TRACE adds http.Handler as router handler
+	// under TRACE method and given patter with optional route name
+	TRACE(pattern string, handler http.Handler, name ...string)
+
+	// USE adds middleware functions ([]MiddlewareFunc)
+	// to whole router branch under given method and patter
+	USE(method, pattern string, fs ...MiddlewareFunc)
+
+	// USEANY adds middleware functions ([]MiddlewareFunc)
+	// to whole router branch for all methods and patter
+	USEANY(pattern string, fs ...MiddlewareFunc)
+
+	// Handle adds http.Handler as router handler
+	// under given method and patter with optional route name,
+	// panics if pattern conflicts with already registered route
+	Handle(method, pattern string, handler http.Handler, name ...string)
+
+	// TryHandle adds http.Handler as router handler like Handle does,
+	// returns *ConflictError instead of panicking when pattern is ambiguous with
+	// or shadowed by already registered route, invalid route name is returned as error too
+	TryHandle(method, pattern string, handler http.Handler, name ...string) error
+
+	// Replace swaps handler of the route registered under given method and exactly the same pattern
+	// keeping its name and middleware, route is registered like with Handle if there is none,
+	// panics if pattern conflicts with already registered route or mounted handler
+	Replace(method, pattern string, handler http.Handler)
+
+	// Remove unregisters route registered under given method and exactly the same pattern,
+	// middleware registered for paths left without routes is removed as well,
+	// mounted handlers are removed per method, reports if route was found
+	Remove(method, pattern string) bool
+
+	// HandleE adds error returning handler as router handler
+	// under given method and patter with optional route name,
+	// returned errors and recovered panics are replied with the error handler
+	HandleE(method, pattern string, handler func(http.ResponseWriter, *http.Request) error, name ...string)
+
+	// URL builds path for the route registered under given name,
+	// params are key value pairs used to fill route wildcards
+	URL(name string, params ...string) (string, error)
+
+	// Mount another handler as a subrouter,
+	// panics if it shadows already registered routes
+	Mount(pattern string, handler http.Handler)
+
+	// Update runs fn publishing all the changes it makes to the Router at once
+	Update(fn func(g RouteGroup))
+
+	// Group registers routes with given prefix joined with the RouteGroup one
+	Group(prefix string, fn func(g RouteGroup))
+
+	// Host returns RouteGroup of the host router scoped to requests which host matches given pattern
+	// registering routes under the same prefix
+	Host(pattern string) RouteGroup
+
+	// Routes lists routes registered under the prefix, including the ones of host routers
+	Routes() []RouteInfo
 }
 
 // FastHTTPRouter is a fasthttp micro framework, HTTP request router, multiplexer, mux
@@ -92,40 +269,40 @@ type FastHTTPRouter interface {
 	PrettyPrint() string
 
 	// POST adds fasthttp.RequestHandler as router handler
-	// under POST method and given patter
-	POST(pattern string, handler fasthttp.RequestHandler)
+	// under POST method and given patter with optional route name
+	POST(pattern string, handler fasthttp.RequestHandler, name ...string)
 
 	// GET adds fasthttp.RequestHandler as router handler
-	// under GET method and given patter
-	GET(pattern string, handler fasthttp.RequestHandler)
+	// under GET method and given patter with optional route name
+	GET(pattern string, handler fasthttp.RequestHandler, name ...string)
 
 	// PUT adds fasthttp.RequestHandler as router handler
-	// under PUT method and given patter
-	PUT(pattern string, handler fasthttp.RequestHandler)
+	// under PUT method and given patter with optional route name
+	PUT(pattern string, handler fasthttp.RequestHandler, name ...string)
 
 	// DELETE adds fasthttp.RequestHandler as router handler
-	// under DELETE method and given patter
-	DELETE(pattern string, handler fasthttp.RequestHandler)
+	// under DELETE method and given patter with optional route name
+	DELETE(pattern string, handler fasthttp.RequestHandler, name ...string)
 
 	// PATCH adds fasthttp.RequestHandler as router handler
-	// under PATCH method and given patter
-	PATCH(pattern string, handler fasthttp.RequestHandler)
+	// under PATCH method and given patter with optional route name
+	PATCH(pattern string, handler fasthttp.RequestHandler, name ...string)
 
 	// OPTIONS adds fasthttp.RequestHandler as router handler
-	// under OPTIONS method and given patter
-	OPTIONS(pattern string, handler fasthttp.RequestHandler)
+	// under OPTIONS method and given patter with optional route name
+	OPTIONS(pattern string, handler fasthttp.RequestHandler, name ...string)
 
 	// HEAD adds fasthttp.RequestHandler as router handler
-	// under HEAD method and given patter
-	HEAD(pattern string, handler fasthttp.RequestHandler)
+	// under HEAD method and given patter with optional route name
+	HEAD(pattern string, handler fasthttp.RequestHandler, name ...string)
 
 	// CONNECT adds fasthttp.RequestHandler as router handler
-	// under CONNECT method and given patter
-	CONNECT(pattern string, handler fasthttp.RequestHandler)
+	// under CONNECT method and given patter with optional route name
+	CONNECT(pattern string, handler fasthttp.RequestHandler, name ...string)
 
 	// TRACE adds fasthttp.RequestHandler as router handler
-	// under TRACE method and given patter
-	TRACE(pattern string, handler fasthttp.RequestHandler)
+	// under TRACE method and given patter with optional route name
+	TRACE(pattern string, handler fasthttp.RequestHandler, name ...string)
 
 	// USE adds middleware functions ([]MiddlewareFunc)
 	// to whole router branch under given method and patter
@@ -136,13 +313,59 @@ type FastHTTPRouter interface {
 	USEANY(pattern string, fs ...FastHTTPMiddlewareFunc)
 
 	// Handle adds fasthttp.RequestHandler as router handler
-	// under given method and patter
-	Handle(method, pattern string, handler fasthttp.RequestHandler)
-
-	// Mount another handler as a subrouter
+	// under given method and patter with optional route name,
+	// panics if pattern conflicts with already registered route
+	Handle(method, pattern string, handler fasthttp.RequestHandler, name ...string)
+
+	// TryHandle adds fasthttp.RequestHandler as router handler like Handle does,
+	// returns *ConflictError instead of panicking when pattern is ambiguous with
+	// or shadowed by already registered route, invalid route name is returned as error too
+	TryHandle(method, pattern string, handler fasthttp.RequestHandler, name ...string) error
+
+	// Replace swaps handler of the route registered under given method and exactly the same pattern
+	// keeping its name and middleware, route is registered like with Handle if there is none,
+	// panics if pattern conflicts with already registered route or mounted handler
+	Replace(method, pattern string, handler fasthttp.RequestHandler)
+
+	// Remove unregisters route registered under given method and exactly the same pattern,
+	// middleware registered for paths left without routes is removed as well,
+	// mounted handlers are removed per method, reports if route was found
+	Remove(method, pattern string) bool
+
+	// HandleE adds error returning handler as router handler
+	// under given method and patter with optional route name,
+	// returned errors and recovered panics are replied with the error handler
+	HandleE(method, pattern string, handler func(*fasthttp.RequestCtx) error, name ...string)
+
+	// URL builds path for the route registered under given name,
+	// params are key value pairs used to fill route wildcards
+	URL(name string, params ...string) (string, error)
+
+	// Mount another handler as a subrouter,
+	// panics if it shadows already registered routes
 	Mount(pattern string, handler fasthttp.RequestHandler)
 
+	// Update runs fn publishing all the changes it makes to the FastHTTPRouter at once,
+	// with concurrent updates enabled requests are served with routes from before fn
+	// until it returns, host routers publish their own changes
+	Update(fn func(r FastHTTPRouter))
+
+	// Group registers routes with given prefix using FastHTTPRouteGroup,
+	// middleware added to the FastHTTPRouteGroup applies under the prefix only
+	Group(prefix string, fn func(g FastHTTPRouteGroup))
+
+	// Host returns FastHTTPRouter scoped to requests which host matches given pattern
+	// (e.g. `{tenant}.example.com`), host wildcards are available along with path params,
+	// requests not matching any host are handled by this FastHTTPRouter
+	Host(pattern string) FastHTTPRouter
+
+	// Routes lists registered routes, routes of scoped host routers are listed
+	// after the ones of this FastHTTPRouter ordered by host pattern
+	Routes() []RouteInfo
+
 	// Compile optimizes Tree nodes reducing static nodes depth when possible
+	// and composes route handlers with their middleware ahead of time,
+	// should be called once all routes and middleware are registered
 	Compile()
 
 	// HandleFastHTTP dispatches the request to the route handler
@@ -160,4 +383,120 @@ type FastHTTPRouter interface {
 	// NotFound replies to the request with the
 	// 405 Error code
 	NotAllowed(fasthttp.RequestHandler)
+
+	// AutoOptions replies to OPTIONS requests when no OPTIONS route is registered
+	// and automatic OPTIONS replies are enabled, Allow header is set before it is called
+	AutoOptions(fasthttp.RequestHandler)
+
+	// OnError sets handler replying to the request when handler registered
+	// with HandleE returns error or panics, by default HTTPError status code is used,
+	// it covers HandleE handlers only, unmatched requests are replied with NotFound and NotAllowed handlers
+	// and panics of other handlers are not recovered by the router unless panic handler option is set
+	OnError(fn func(ctx *fasthttp.RequestCtx, err error))
+
+	// TrailingSlash sets trailing slash policy, TrailingSlashLenient by default
+	TrailingSlash(policy TrailingSlashPolicy)
+
+	// RedirectCleanPath enables redirect of requests with not clean path
+	// (e.g. `/a//b/../c`) to the cleaned one, disabled by default
+	RedirectCleanPath(redirect bool)
+
+	// CaseInsensitive enables case-insensitive matching of static route parts,
+	// with redirect requests are redirected to the registered path casing,
+	// wildcard and regexp values are kept in their original case
+	CaseInsensitive(redirect bool)
+}
+
+// FastHTTPRouteGroup registers routes of the FastHTTPRouter under the path prefix,
+// settings of the whole router can be changed with the FastHTTPRouter only
+type FastHTTPRouteGroup interface {
+	// POST adds fasthttp.RequestHandler as router handler
+	// under POST method and given patter with optional route name
+	POST(pattern string, handler fasthttp.RequestHandler, name ...string)
+
+	// GET adds fasthttp.RequestHandler as router handler
+	// under GET method and given patter with optional route name
+	GET(pattern string, handler fasthttp.RequestHandler, name ...string)
+
+	// PUT adds fasthttp.RequestHandler as router handler
+	// under PUT method and given patter with optional route name
+	PUT(pattern string, handler fasthttp.RequestHandler, name ...string)
+
+	// DELETE adds fasthttp.RequestHandler as router handler
+	// under DELETE method and given patter with optional route name
+	DELETE(pattern string, handler fasthttp.RequestHandler, name ...string)
+
+	// PATCH adds fasthttp.RequestHandler as router handler
+	// under PATCH method and given patter with optional route name
+	PATCH(pattern string, handler fasthttp.RequestHandler, name ...string)
+
+	// OPTIONS adds fasthttp.RequestHandler as router handler
+	// under OPTIONS method and given patter with optional route name
+	OPTIONS(pattern string, handler fasthttp.RequestHandler, name ...string)
+
+	// HEAD adds fasthttp.RequestHandler as router handler
+	// under HEAD method and given patter with optional route name
+	HEAD(pattern string, handler fasthttp.RequestHandler, name ...string)
+
+	// CONNECT adds fasthttp.RequestHandler as router handler
+	// under CONNECT method and given patter with optional route name
+	CONNECT(pattern string, handler fasthttp.RequestHandler, name ...string)
+
+	// TRACE adds fasthttp.RequestHandler as router handler
+	// under TRACE method and given patter with optional route name
+	TRACE(pattern string, handler fasthttp.RequestHandler, name ...string)
+
+	// USE adds middleware functions ([]MiddlewareFunc)
+	// to whole router branch under given method and patter
+	USE(method, pattern string, fs ...FastHTTPMiddlewareFunc)
+
+	// USEANY adds middleware functions ([]MiddlewareFunc)
+	// to whole router branch for all methods and patter
+	USEANY(pattern string, fs ...FastHTTPMiddlewareFunc)
+
+	// Handle adds fasthttp.RequestHandler as router handler
+	// under given method and patter with optional route name,
+	// panics if pattern conflicts with already registered route
+	Handle(method, pattern string, handler fasthttp.RequestHandler, name ...string)
+
+	// TryHandle adds fasthttp.RequestHandler as router handler like Handle does,
+	// returns *ConflictError instead of panicking when pattern is ambiguous with
+	// or shadowed by already registered route, invalid route name is returned as error too
+	TryHandle(method, pattern string, handler fasthttp.RequestHandler, name ...string) error
+
+	// Replace swaps handler of the route registered under given method and exactly the same pattern
+	// keeping its name and middleware, route is registered like with Handle if there is none,
+	// panics if pattern conflicts with already registered route or mounted handler
+	Replace(method, pattern string, handler fasthttp.RequestHandler)
+
+	// Remove unregisters route registered under given method and exactly the same pattern,
+	// middleware registered for paths left without routes is removed as well,
+	// mounted handlers are removed per method, reports if route was found
+	Remove(method, pattern string) bool
+
+	// HandleE adds error returning handler as router handler
+	// under given method and patter with optional route name,
+	// returned errors and recovered panics are replied with the error handler
+	HandleE(method, pattern string, handler func(*fasthttp.RequestCtx) error, name ...string)
+
+	// URL builds path for the route registered under given name,
+	// params are key value pairs used to fill route wildcards
+	URL(name string, params ...string) (string, error)
+
+	// Mount another handler as a subrouter,
+	// panics if it shadows already registered routes
+	Mount(pattern string, handler fasthttp.RequestHandler)
+
+	// Update runs fn publishing all the changes it makes to the FastHTTPRouter at once
+	Update(fn func(g FastHTTPRouteGroup))
+
+	// Group registers routes with given prefix joined with the FastHTTPRouteGroup one
+	Group(prefix string, fn func(g FastHTTPRouteGroup))
+
+	// Host returns FastHTTPRouteGroup of the host router scoped to requests which host matches given pattern
+	// registering routes under the same prefix
+	Host(pattern string) FastHTTPRouteGroup
+
+	// Routes lists routes registered under the prefix, including the ones of host routers
+	Routes() []RouteInfo
 }
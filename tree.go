@@ -1,42 +1,272 @@
 package gorouter
 
 import (
+	"fmt"
 	"net/http"
 
+	"github.com/vardius/gorouter/v4/context"
+	"github.com/vardius/gorouter/v4/middleware"
 	"github.com/vardius/gorouter/v4/mux"
+	pathutils "github.com/vardius/gorouter/v4/path"
 )
 
-func allowed(t mux.Tree, method, path string) (allow string) {
-	if path == "*" {
-		// tree roots should be http method nodes only
-		for _, root := range t {
-			if root.Name() == http.MethodOptions {
-				continue
-			}
-			if len(allow) == 0 {
-				allow = root.Name()
-			} else {
-				allow += ", " + root.Name()
-			}
+func allowed(t mux.Tree, method, path string, headFallback, strictSlash bool) (allow string) {
+	var hasGet, hasHead bool
+
+	asterisk := pathutils.TrimSlash(path) == "*"
+
+	// tree roots should be http method nodes only
+	for _, root := range t {
+		name := root.Name()
+		if name == http.MethodOptions {
+			continue
 		}
-	} else {
-		// tree roots should be http method nodes only
-		for _, root := range t {
-			if root.Name() == method || root.Name() == http.MethodOptions {
-				continue
-			}
 
-			if route, _ := root.Tree().MatchRoute(path); route != nil {
-				if len(allow) == 0 {
-					allow = root.Name()
-				} else {
-					allow += ", " + root.Name()
-				}
-			}
+		// test all tree "*" paths
+		if !asterisk && (name == method || !hasRoute(root, path, strictSlash)) {
+			continue
 		}
+
+		hasGet = hasGet || name == http.MethodGet
+		hasHead = hasHead || name == http.MethodHead
+		allow = appendMethod(allow, name)
 	}
+
+	// HEAD requests are served by GET routes
+	if headFallback && hasGet && !hasHead && method != http.MethodHead {
+		allow = appendMethod(allow, http.MethodHead)
+	}
+
 	if len(allow) > 0 {
 		allow += ", " + http.MethodOptions
 	}
 	return allow
 }
+
+// hasRoute checks if method root node has route matching request path,
+// with strictSlash route has to match request path trailing slash
+func hasRoute(root mux.Node, path string, strictSlash bool) bool {
+	var rt mux.Route
+	if trimmed := pathutils.TrimSlash(path); trimmed == "" {
+		rt = root.Route()
+	} else {
+		rt, _ = root.Tree().MatchRoute(trimmed)
+	}
+
+	if rt == nil {
+		return false
+	}
+
+	return !strictSlash || rt.(*route).matchesSlash(path)
+}
+
+func appendMethod(allow, method string) string {
+	if len(allow) == 0 {
+		return method
+	}
+
+	return allow + ", " + method
+}
+
+func buildURL(t mux.Tree, routes namedRoutes, name string, params ...string) (string, error) {
+	r, ok := routes[name]
+	if !ok {
+		return "", fmt.Errorf("route %q not found", name)
+	}
+	if len(params)%2 != 0 {
+		return "", fmt.Errorf("route %q: params have to be given as key value pairs", name)
+	}
+
+	p := make(context.Params, 0, len(params)/2)
+	for i := 0; i < len(params); i += 2 {
+		p = append(p, context.Param{Key: params[i], Value: params[i+1]})
+	}
+
+	root := t.Find(r.method)
+	if root == nil {
+		return "", fmt.Errorf("route %q not found", name)
+	}
+	if root.Route() == r {
+		if len(p) == 0 {
+			return "/", nil
+		}
+
+		// route with optional wildcards may be set to the root node too, e.g. /{page?}
+		if root.Tree().FindRoute(r.pattern.Pattern) != r {
+			return "", fmt.Errorf("route %q: param %q is not used by the route", name, p[0].Key)
+		}
+	}
+
+	url, err := root.Tree().URL(r.pattern.Pattern, p)
+	if err != nil {
+		return "", fmt.Errorf("route %q: %w", name, err)
+	}
+
+	if r.trailingSlash {
+		url += "/"
+	}
+
+	return url, nil
+}
+
+// routesInfo lists routes registered within method tree, each of them once with its registered pattern
+func routesInfo(t mux.Tree) []RouteInfo {
+	var routes []RouteInfo
+
+	for _, root := range t {
+		rootMiddleware := len(root.Middleware())
+
+		rootIndex := len(routes)
+		if r, ok := root.Route().(*route); ok {
+			routes = append(routes, newRouteInfo(root.Name(), r, mux.RouteInfo{Middleware: rootMiddleware}))
+		}
+
+		for _, info := range root.Tree().Routes() {
+			info.Middleware += rootMiddleware
+
+			// route with optional wildcards may be set to the root node too, e.g. /{page?}
+			if info.Route == root.Route() {
+				routes[rootIndex] = newRouteInfo(root.Name(), info.Route.(*route), info)
+				continue
+			}
+
+			routes = append(routes, newRouteInfo(root.Name(), info.Route.(*route), info))
+		}
+	}
+
+	return routes
+}
+
+// matchTreeMiddleware collects middleware of the method root tree nodes matching path,
+// path is empty for the root route
+func matchTreeMiddleware(root mux.Node, path string) middleware.Collection {
+	if path == "" {
+		return nil
+	}
+
+	return root.Tree().MatchMiddleware(path)
+}
+
+// composedHandlers maps routes to their handlers composed with middleware ahead of time,
+// routes are shared between published trees, so handlers are kept with each of them
+type composedHandlers map[*route]interface{}
+
+// computeHandlers composes route handlers with middleware ahead of time
+// for routes which middleware does not depend on the request path
+func computeHandlers(t mux.Tree) composedHandlers {
+	handlers := make(composedHandlers)
+	seen := make(map[*route]bool)
+	compute := func(r *route, m middleware.Collection, static bool) {
+		if seen[r] || !static {
+			// route shared between nodes may have different middleware for each of them
+			delete(handlers, r)
+		} else {
+			handlers[r] = m.Sort().Compose(r.handler)
+		}
+
+		seen[r] = true
+	}
+
+	// tree roots should be http method nodes only
+	for _, root := range t {
+		if r, ok := root.Route().(*route); ok {
+			m := make(middleware.Collection, len(root.Middleware()))
+			copy(m, root.Middleware())
+
+			compute(r, m, true)
+		}
+
+		root.Tree().RouteMiddleware(root.Middleware(), func(r mux.Route, m middleware.Collection, static bool) {
+			compute(r.(*route), m, static)
+		})
+	}
+
+	return handlers
+}
+
+// compileTree optimizes method trees, method root nodes are kept
+func compileTree(t mux.Tree) {
+	for i, methodNode := range t {
+		t[i].WithChildren(methodNode.Tree().Compile())
+	}
+}
+
+// findRoute finds node of the route registered under the method and exactly the same pattern,
+// pattern has to be cleaned the same way route patterns are when registered
+func findRoute(t mux.Tree, method, pattern string) (mux.Node, *route) {
+	node := t.Find(method)
+	if node != nil && pathutils.TrimSlash(pattern) != "" {
+		node = node.Tree().FindNode(pattern)
+	}
+	if node == nil {
+		return nil, nil
+	}
+
+	if r, ok := node.Route().(*route); ok && r.pattern.Pattern == pattern {
+		return node, r
+	}
+
+	return nil, nil
+}
+
+// removeRoute removes route registered under the method and exactly the same pattern,
+// method root node is kept together with its middleware
+func removeRoute(t mux.Tree, method, pattern string) (mux.Tree, *route) {
+	node, r := findRoute(t, method, pattern)
+	if r == nil {
+		return t, nil
+	}
+
+	root := t.Find(method)
+	switch {
+	case node == root && root.Kind() == mux.KindSubrouter:
+		// handler mounted under root path, nothing else can be registered for the method
+		t = t.WithoutRoute(method)
+	case node == root:
+		root.WithRoute(nil)
+	default:
+		root.WithChildren(root.Tree().WithoutRoute(pattern))
+
+		// route with optional wildcards may be set to the root node too, e.g. /{page?}
+		if root.Route() == r {
+			root.WithRoute(nil)
+		}
+	}
+
+	return t, r
+}
+
+// replaceRoute swaps handler of the route registered under the method and exactly the same pattern,
+// new route keeps the name, mounted handlers are not replaced
+func replaceRoute(t mux.Tree, routes namedRoutes, method, pattern string, h interface{}) bool {
+	_, r := findRoute(t, method, pattern)
+	if r == nil || r.subrouter {
+		return false
+	}
+
+	// routes are never changed, they may be served from published trees
+	replacement := newRoute(h)
+	replacement.method = r.method
+	replacement.name = r.name
+	replacement.trailingSlash = r.trailingSlash
+	replacement.pattern = r.pattern
+
+	// route with optional wildcards is set to node of every expanded path
+	root := t.Find(method)
+	if root.Route() == r {
+		root.WithRoute(replacement)
+	}
+	_ = mux.Walk(root.Tree(), func(_ []mux.Node, node mux.Node) error {
+		if node.Route() == r {
+			node.WithRoute(replacement)
+		}
+
+		return nil
+	})
+
+	if r.name != "" {
+		routes[r.name] = replacement
+	}
+
+	return true
+}